@@ -12,17 +12,20 @@ type API struct {
 	db             *gorm.DB
 	redisClient    *redis.Client
 	messageService *service.MessageService
+	callbackAuth   SignatureConfig
 }
 
 func NewAPI(
 	db *gorm.DB,
 	redisClient *redis.Client,
 	messageService *service.MessageService,
+	callbackAuth SignatureConfig,
 ) *API {
 	return &API{
 		db:             db,
 		redisClient:    redisClient,
 		messageService: messageService,
+		callbackAuth:   callbackAuth,
 	}
 }
 
@@ -31,5 +34,19 @@ func (r *API) RegisterRoutes(router *chi.Mux) {
 
 	router.Get("/start", messageHandler.StartProcess)
 	router.Get("/stop", messageHandler.StopProcess)
+	router.Post("/process-now", messageHandler.ProcessNow)
+	router.Get("/status", messageHandler.Status)
 	router.Get("/messages", messageHandler.Retrieve)
+	router.Post("/messages", messageHandler.Create)
+	router.Post("/messages/bulk", messageHandler.Import)
+	router.Get("/messages/{id}", messageHandler.Get)
+	router.Get("/messages/provider/{provider}/{messageId}", messageHandler.GetByProviderMessageID)
+
+	router.Group(func(callbacks chi.Router) {
+		callbacks.Use(VerifySignature(r.callbackAuth, NewRedisNonceStore(r.redisClient)))
+		callbacks.Post("/callbacks/delivery", messageHandler.DeliveryReport)
+	})
+
+	router.Get("/admin/rate", messageHandler.GetRate)
+	router.Put("/admin/rate", messageHandler.UpdateRate)
 }
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"github.com/busragumusel/insider-case/internal/handler"
+	"github.com/busragumusel/insider-case/internal/sender"
+	"github.com/go-redis/redis/v8"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultProviderHeader     = "X-Provider"
+	defaultTimestampTolerance = 5 * time.Minute
+
+	maxCallbackBodySize = 1 << 20
+)
+
+// SignatureConfig configures how inbound callbacks are authenticated. Providers
+// sign callbacks the same way the webhook sender signs outbound requests, each
+// with its own secret, and name themselves in ProviderHeader.
+type SignatureConfig struct {
+	Secrets         map[string]string
+	ProviderHeader  string
+	SignatureHeader string
+	TimestampHeader string
+	Tolerance       time.Duration
+}
+
+// SignatureConfigFromEnv reads provider secrets from CALLBACK_SECRETS as
+// comma separated name=secret pairs.
+func SignatureConfigFromEnv() SignatureConfig {
+	config := SignatureConfig{Secrets: map[string]string{}}
+
+	for _, pair := range strings.Split(os.Getenv("CALLBACK_SECRETS"), ",") {
+		name, secret, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && name != "" && secret != "" {
+			config.Secrets[name] = secret
+		}
+	}
+
+	config.ProviderHeader = os.Getenv("CALLBACK_PROVIDER_HEADER")
+	config.SignatureHeader = os.Getenv("CALLBACK_SIGNATURE_HEADER")
+	config.TimestampHeader = os.Getenv("CALLBACK_TIMESTAMP_HEADER")
+	if value, err := time.ParseDuration(os.Getenv("CALLBACK_TIMESTAMP_TOLERANCE")); err == nil && value > 0 {
+		config.Tolerance = value
+	}
+
+	return config
+}
+
+// NonceStore remembers the callbacks that were already accepted.
+type NonceStore interface {
+	// Add records nonce for ttl and reports false if it was already recorded.
+	Add(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+	// Remove forgets nonce, so that the request can be made again.
+	Remove(ctx context.Context, nonce string) error
+}
+
+type redisNonceStore struct {
+	client *redis.Client
+}
+
+func NewRedisNonceStore(client *redis.Client) NonceStore {
+	return &redisNonceStore{client: client}
+}
+
+func (s *redisNonceStore) Add(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, nonceKey(nonce), 1, ttl).Result()
+}
+
+func (s *redisNonceStore) Remove(ctx context.Context, nonce string) error {
+	return s.client.Del(ctx, nonceKey(nonce)).Err()
+}
+
+func nonceKey(nonce string) string {
+	return "callback_nonce:" + nonce
+}
+
+// VerifySignature rejects requests that aren't signed by a known provider,
+// whose timestamp is outside the tolerance, or that were already accepted
+// once. The signature doubles as the nonce: it covers the timestamp and body,
+// so a replay within the tolerance carries the same one. A request that isn't
+// answered with a 2xx status doesn't count as accepted, so the provider can
+// retry it.
+func VerifySignature(config SignatureConfig, nonces NonceStore) func(http.Handler) http.Handler {
+	if config.ProviderHeader == "" {
+		config.ProviderHeader = defaultProviderHeader
+	}
+	if config.SignatureHeader == "" {
+		config.SignatureHeader = sender.DefaultSignatureHeader
+	}
+	if config.TimestampHeader == "" {
+		config.TimestampHeader = sender.DefaultTimestampHeader
+	}
+	if config.Tolerance <= 0 {
+		config.Tolerance = defaultTimestampTolerance
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			provider := req.Header.Get(config.ProviderHeader)
+			secret, ok := config.Secrets[provider]
+			if !ok {
+				writeError(w, http.StatusUnauthorized, "INVALID_SIGNATURE", "Unknown provider")
+				return
+			}
+
+			timestamp := req.Header.Get(config.TimestampHeader)
+			sentAt, err := strconv.ParseInt(timestamp, 10, 64)
+			if err != nil {
+				writeError(w, http.StatusUnauthorized, "INVALID_SIGNATURE", "Missing or invalid timestamp")
+				return
+			}
+			if age := time.Since(time.Unix(sentAt, 0)); age > config.Tolerance || age < -config.Tolerance {
+				writeError(w, http.StatusUnauthorized, "INVALID_SIGNATURE", "Timestamp outside the allowed tolerance")
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(req.Body, maxCallbackBodySize))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "", "Failed to read request body")
+				return
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			signature := req.Header.Get(config.SignatureHeader)
+			if !hmac.Equal([]byte(signature), []byte(sender.Sign(secret, timestamp, body))) {
+				writeError(w, http.StatusUnauthorized, "INVALID_SIGNATURE", "Signature does not match")
+				return
+			}
+
+			// a nonce must outlive every timestamp that is still accepted
+			nonce := provider + ":" + signature
+			added, err := nonces.Add(req.Context(), nonce, 2*config.Tolerance)
+			if err != nil {
+				log.Printf("failed to record callback nonce: %v", err)
+				writeError(w, http.StatusInternalServerError, "", "Failed to verify request")
+				return
+			}
+			if !added {
+				writeError(w, http.StatusConflict, "REPLAYED_REQUEST", "Request was already processed")
+				return
+			}
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, req.WithContext(handler.WithCallbackProvider(req.Context(), provider)))
+
+			if recorder.status < 200 || recorder.status >= 300 {
+				if err := nonces.Remove(context.WithoutCancel(req.Context()), nonce); err != nil {
+					log.Printf("failed to forget callback nonce: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// statusRecorder remembers the status code a handler responded with.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(handler.APIError{Code: code, Message: message})
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/busragumusel/insider-case/internal/sender"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]bool
+}
+
+func (s *memoryNonceStore) Add(_ context.Context, nonce string, _ time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.nonces[nonce] {
+		return false, nil
+	}
+	s.nonces[nonce] = true
+	return true, nil
+}
+
+func (s *memoryNonceStore) Remove(_ context.Context, nonce string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.nonces, nonce)
+	return nil
+}
+
+func signedRequest(provider, secret string, sentAt time.Time, body string) *http.Request {
+	timestamp := strconv.FormatInt(sentAt.Unix(), 10)
+
+	req := httptest.NewRequest("POST", "/callbacks/delivery", strings.NewReader(body))
+	req.Header.Set("X-Provider", provider)
+	req.Header.Set(sender.DefaultTimestampHeader, timestamp)
+	req.Header.Set(sender.DefaultSignatureHeader, sender.Sign(secret, timestamp, []byte(body)))
+	return req
+}
+
+func TestVerifySignature(t *testing.T) {
+	var received string
+	next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		assert.NoError(t, err)
+		received = string(body)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	middleware := VerifySignature(SignatureConfig{
+		Secrets:   map[string]string{"webhook": "webhook-secret", "smsc": "smsc-secret"},
+		Tolerance: time.Minute,
+	}, &memoryNonceStore{nonces: map[string]bool{}})
+	handler := middleware(next)
+
+	body := `{"messageId":"provider-1","status":"delivered"}`
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		req    *http.Request
+		status int
+	}{
+		{"valid", signedRequest("webhook", "webhook-secret", now, body), http.StatusOK},
+		{"replayed", signedRequest("webhook", "webhook-secret", now, body), http.StatusConflict},
+		{"other provider", signedRequest("smsc", "smsc-secret", now, body), http.StatusOK},
+		{"wrong secret", signedRequest("smsc", "webhook-secret", now.Add(time.Second), body), http.StatusUnauthorized},
+		{"unknown provider", signedRequest("other", "webhook-secret", now, body), http.StatusUnauthorized},
+		{"expired", signedRequest("webhook", "webhook-secret", now.Add(-2*time.Minute), body), http.StatusUnauthorized},
+		{"future", signedRequest("webhook", "webhook-secret", now.Add(2*time.Minute), body), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, tt.req)
+
+		assert.Equal(t, tt.status, rr.Code, tt.name)
+	}
+
+	assert.Equal(t, body, received)
+
+	req := signedRequest("webhook", "webhook-secret", now.Add(time.Second), body)
+	req.Body = http.NoBody
+	req.ContentLength = 0
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, "tampered body")
+}
+
+func TestVerifySignatureAllowsRetryAfterFailure(t *testing.T) {
+	statuses := []int{http.StatusInternalServerError, http.StatusNotFound, http.StatusOK}
+	next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(statuses[0])
+		statuses = statuses[1:]
+	})
+
+	middleware := VerifySignature(SignatureConfig{
+		Secrets: map[string]string{"webhook": "webhook-secret"},
+	}, &memoryNonceStore{nonces: map[string]bool{}})
+	handler := middleware(next)
+
+	body := `{"messageId":"provider-1","status":"delivered"}`
+	now := time.Now()
+
+	for _, status := range []int{http.StatusInternalServerError, http.StatusNotFound, http.StatusOK, http.StatusConflict} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, signedRequest("webhook", "webhook-secret", now, body))
+
+		assert.Equal(t, status, rr.Code)
+	}
+}
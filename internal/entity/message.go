@@ -3,15 +3,32 @@ package entity
 import "time"
 
 const (
-	StatusPending = "pending"
-	StatusSent    = "sent"
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusSent       = "sent"
+	StatusFailed     = "failed"
+
+	StatusDelivered   = "delivered"
+	StatusUndelivered = "undelivered"
 )
 
 type Message struct {
 	ID          uint      `gorm:"primaryKey"`
 	PhoneNumber string    `gorm:"size:20;not null"`
 	Content     string    `gorm:"size:160;not null"`
-	Status      string    `gorm:"size:10;default:pending"`
+	Status      string    `gorm:"size:20;default:pending"`
 	CreatedAt   time.Time `gorm:"default:null"`
 	SentAt      time.Time `gorm:"default:null"`
+	DeliveredAt time.Time `gorm:"default:null"`
+
+	ProviderName      string `gorm:"size:50;index:idx_messages_provider,priority:1"`
+	ProviderMessageID string `gorm:"size:100;index:idx_messages_provider,priority:2"`
+	ProviderResponse  string `gorm:"type:text"`
+
+	AttemptCount  int       `gorm:"not null;default:0"`
+	LastError     string    `gorm:"type:text"`
+	NextAttemptAt time.Time `gorm:"default:null;index"`
+
+	ClaimedBy string    `gorm:"size:100"`
+	ClaimedAt time.Time `gorm:"default:null;index"`
 }
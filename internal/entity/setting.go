@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// Setting is a named value that has to survive restarts, together with who
+// changed it last and when.
+type Setting struct {
+	Key       string    `gorm:"primaryKey;size:50"`
+	Value     string    `gorm:"size:255;not null"`
+	ChangedBy string    `gorm:"size:100"`
+	ChangedAt time.Time `gorm:"default:null"`
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/busragumusel/insider-case/internal/model"
+	"github.com/busragumusel/insider-case/internal/service"
+	"net/http"
+	"time"
+)
+
+// GetRate returns the processing rate
+// @Summary Get processing rate
+// @Description Returns how often messages are processed and how many are sent per run.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} APIResult{data=model.RateConfig}
+// @Router /admin/rate [get]
+func (r *MessageHandler) GetRate(w http.ResponseWriter, req *http.Request) {
+	interval, batchSize := r.service.Rate()
+
+	writeJSONResponse(w, http.StatusOK, APIResult{
+		Data: model.RateConfig{
+			Interval:  interval.String(),
+			BatchSize: batchSize,
+		},
+	})
+}
+
+// UpdateRate changes the processing rate
+// @Summary Update processing rate
+// @Description Changes the processing interval and batch size of every instance and stores them. A running process picks them up without a restart.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param rate body model.RateConfig true "Interval as a Go duration and batch size"
+// @Success 200 {object} APIResult{data=model.RateConfig}
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /admin/rate [put]
+func (r *MessageHandler) UpdateRate(w http.ResponseWriter, req *http.Request) {
+	var body model.RateConfig
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, APIError{
+			Message: "Invalid request body",
+		})
+		return
+	}
+
+	interval, batchSize := r.service.Rate()
+
+	if body.Interval != "" {
+		parsed, err := time.ParseDuration(body.Interval)
+		if err != nil {
+			writeJSONResponse(w, http.StatusBadRequest, APIError{
+				Code:    "VALIDATION_ERROR",
+				Message: "interval: must be a duration such as 30s or 2m",
+			})
+			return
+		}
+		interval = parsed
+	}
+	if body.BatchSize != 0 {
+		batchSize = body.BatchSize
+	}
+
+	if err := r.service.SetRate(req.Context(), interval, batchSize); err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			writeJSONResponse(w, http.StatusBadRequest, APIError{
+				Code:    "VALIDATION_ERROR",
+				Message: validationErr.Error(),
+			})
+			return
+		}
+
+		writeJSONResponse(w, http.StatusInternalServerError, APIError{
+			Message: "Failed to update rate",
+		})
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, APIResult{
+		Data: model.RateConfig{
+			Interval:  interval.String(),
+			BatchSize: batchSize,
+		},
+	})
+}
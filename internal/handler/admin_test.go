@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/busragumusel/insider-case/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRate(t *testing.T) {
+	service := &mockMessageService{}
+	handler := NewMessageHandler(service)
+
+	req, err := http.NewRequest("GET", "/admin/rate", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.GetRate(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Data model.RateConfig `json:"data"`
+	}
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "2m0s", response.Data.Interval)
+	assert.Equal(t, 2, response.Data.BatchSize)
+}
+
+func TestUpdateRate(t *testing.T) {
+	service := &mockMessageService{}
+	handler := NewMessageHandler(service)
+
+	req, err := http.NewRequest("PUT", "/admin/rate", strings.NewReader(`{"interval":"30s"}`))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.UpdateRate(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Data model.RateConfig `json:"data"`
+	}
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "30s", response.Data.Interval)
+	assert.Equal(t, 2, response.Data.BatchSize)
+}
+
+func TestUpdateRateInvalid(t *testing.T) {
+	service := &mockMessageService{}
+	handler := NewMessageHandler(service)
+
+	for _, body := range []string{`{"interval":"soon"}`, `{"interval":"10ms"}`, `{`} {
+		req, err := http.NewRequest("PUT", "/admin/rate", strings.NewReader(body))
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		handler.UpdateRate(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+	}
+}
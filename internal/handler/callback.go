@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/busragumusel/insider-case/internal/model"
+	"github.com/busragumusel/insider-case/internal/service"
+	"net/http"
+)
+
+type callbackProviderKey struct{}
+
+// WithCallbackProvider returns a copy of ctx naming the provider a callback
+// was authenticated as.
+func WithCallbackProvider(ctx context.Context, provider string) context.Context {
+	return context.WithValue(ctx, callbackProviderKey{}, provider)
+}
+
+func callbackProvider(ctx context.Context) string {
+	provider, _ := ctx.Value(callbackProviderKey{}).(string)
+	return provider
+}
+
+// DeliveryReport records a provider delivery receipt
+// @Summary Delivery report callback
+// @Description Marks the message the provider accepted with the given message id as delivered or undelivered.
+// @Description Interim and duplicate reports are accepted and leave the message unchanged.
+// @Tags Callback
+// @Accept json
+// @Produce json
+// @Param X-Provider header string true "Provider name"
+// @Param X-Timestamp header string true "Unix time the report was sent"
+// @Param X-Signature header string true "sha256=<hex HMAC-SHA256 of timestamp.body>"
+// @Param report body model.DeliveryReport true "Delivery report"
+// @Success 200 {object} APIResult{data=model.MessageDetail}
+// @Failure 400 {object} APIError
+// @Failure 401 {object} APIError
+// @Failure 404 {object} APIError
+// @Failure 409 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /callbacks/delivery [post]
+func (r *MessageHandler) DeliveryReport(w http.ResponseWriter, req *http.Request) {
+	var body model.DeliveryReport
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, APIError{
+			Message: "Invalid request body",
+		})
+		return
+	}
+	body.Provider = callbackProvider(req.Context())
+
+	message, err := r.service.RecordDelivery(req.Context(), body)
+	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			writeJSONResponse(w, http.StatusBadRequest, APIError{
+				Code:    "VALIDATION_ERROR",
+				Message: validationErr.Error(),
+			})
+			return
+		}
+		if errors.Is(err, service.ErrMessageNotFound) {
+			writeJSONResponse(w, http.StatusNotFound, APIError{
+				Code:    "NOT_FOUND",
+				Message: "Message not found",
+			})
+			return
+		}
+
+		writeJSONResponse(w, http.StatusInternalServerError, APIError{
+			Message: "Failed to record delivery report",
+		})
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, APIResult{
+		Data: message,
+	})
+}
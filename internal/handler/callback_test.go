@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeliveryReport(t *testing.T) {
+	service := &mockMessageService{}
+	handler := NewMessageHandler(service)
+
+	tests := []struct {
+		provider string
+		body     string
+		status   int
+	}{
+		{"webhook", `{"messageId":"67f2f8a8-ea58-4ed0-a6f9-ff217df4d849","status":"delivered"}`, http.StatusOK},
+		{"backup", `{"messageId":"67f2f8a8-ea58-4ed0-a6f9-ff217df4d849","status":"delivered"}`, http.StatusNotFound},
+		{"webhook", `{"messageId":"unknown","status":"delivered"}`, http.StatusNotFound},
+		{"webhook", `{"messageId":"67f2f8a8-ea58-4ed0-a6f9-ff217df4d849","status":"lost"}`, http.StatusBadRequest},
+		{"webhook", `{`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest("POST", "/callbacks/delivery", strings.NewReader(tt.body))
+		assert.NoError(t, err)
+		req = req.WithContext(WithCallbackProvider(req.Context(), tt.provider))
+
+		rr := httptest.NewRecorder()
+		handler.DeliveryReport(rr, req)
+
+		assert.Equal(t, tt.status, rr.Code, tt.body)
+	}
+}
@@ -3,8 +3,16 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/busragumusel/insider-case/internal/model"
 	"github.com/busragumusel/insider-case/internal/service"
+	"github.com/go-chi/chi/v5"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 )
 
 type MessageHandler struct {
@@ -26,44 +34,151 @@ func writeJSONResponse(w http.ResponseWriter, statusCode int, response interface
 
 // StartProcess starts message processing
 // @Summary Start message processing
-// @Description Starts the background process that handles messages.
+// @Description Starts the background process that handles messages on every instance. The state is kept across restarts.
 // @Tags Message
 // @Produce json
-// @Success 200 {object} APIResult
+// @Param X-Operator header string false "Who is starting processing, recorded as changed_by"
+// @Success 200 {object} APIResult{data=model.ProcessingState}
+// @Failure 500 {object} APIError
 // @Router /start [get]
 func (r *MessageHandler) StartProcess(w http.ResponseWriter, req *http.Request) {
-	go r.service.StartProcess(context.Background())
-	writeJSONResponse(w, http.StatusOK, nil)
+	state, err := r.service.RequestStart(req.Context(), changedBy(req))
+	if err != nil {
+		writeJSONResponse(w, http.StatusInternalServerError, APIError{
+			Message: "Failed to start processing",
+		})
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, APIResult{
+		Data: state,
+	})
 }
 
 // StopProcess stops message processing
 // @Summary Stop message processing
-// @Description Stops the message processing Goroutine.
+// @Description Stops the message processing Goroutine on every instance. The state is kept across restarts.
 // @Tags Message
 // @Produce json
-// @Success 200 {object} APIResult
+// @Param X-Operator header string false "Who is stopping processing, recorded as changed_by"
+// @Success 200 {object} APIResult{data=model.ProcessingState}
+// @Failure 500 {object} APIError
 // @Router /stop [get]
 func (r *MessageHandler) StopProcess(w http.ResponseWriter, req *http.Request) {
-	r.service.StopProcess()
-	writeJSONResponse(w, http.StatusOK, nil)
+	state, err := r.service.RequestStop(req.Context(), changedBy(req))
+	if err != nil {
+		writeJSONResponse(w, http.StatusInternalServerError, APIError{
+			Message: "Failed to stop processing",
+		})
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, APIResult{
+		Data: state,
+	})
+}
+
+// changedBy names who made a request, the X-Operator header if set and the
+// client address otherwise.
+func changedBy(req *http.Request) string {
+	if operator := req.Header.Get("X-Operator"); operator != "" {
+		return operator
+	}
+
+	return req.RemoteAddr
+}
+
+// Status reports the state of message processing
+// @Summary Processing status
+// @Description Reports whether processing is running, when it last ran with which result and when it runs next.
+// @Tags Message
+// @Produce json
+// @Success 200 {object} APIResult{data=model.ProcessStatus}
+// @Router /status [get]
+func (r *MessageHandler) Status(w http.ResponseWriter, req *http.Request) {
+	writeJSONResponse(w, http.StatusOK, APIResult{
+		Data: r.service.Status(),
+	})
+}
+
+// ProcessNow sends one batch immediately
+// @Summary Process messages now
+// @Description Claims and sends one batch of pending messages synchronously and returns the outcome of each message.
+// @Tags Message
+// @Produce json
+// @Success 200 {object} APIResult{data=[]model.ProcessResult}
+// @Failure 500 {object} APIError
+// @Failure 503 {object} APIError
+// @Router /process-now [post]
+func (r *MessageHandler) ProcessNow(w http.ResponseWriter, req *http.Request) {
+	results, err := r.service.ProcessNow(req.Context())
+	if errors.Is(err, service.ErrProvidersUnavailable) {
+		writeJSONResponse(w, http.StatusServiceUnavailable, APIError{
+			Code:    "PROVIDERS_UNAVAILABLE",
+			Message: "All providers are unavailable",
+		})
+		return
+	}
+	if errors.Is(err, service.ErrRateLimited) {
+		writeJSONResponse(w, http.StatusServiceUnavailable, APIError{
+			Code:    "RATE_LIMITED",
+			Message: "Providers are rate limiting, sending is paused",
+		})
+		return
+	}
+	if err != nil {
+		writeJSONResponse(w, http.StatusInternalServerError, APIError{
+			Message: "Failed to process messages",
+		})
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, APIResult{
+		Data: results,
+	})
 }
 
-// Retrieve fetches all sent messages
-// @Summary Retrieve sent messages
-// @Description Fetches all sent messages from the database.
+// Retrieve fetches messages page by page
+// @Summary Retrieve messages
+// @Description Fetches messages ordered by creation time using cursor based pagination.
 // @Tags Message
 // @Produce json
-// @Param status query string false "Message status filter" default(sent)
-// @Success 200 {object} APIResult
+// @Param status query string false "Message status filter"
+// @Param phone query string false "Recipient phone number filter"
+// @Param created_from query string false "Created at or after (RFC3339)"
+// @Param created_to query string false "Created before (RFC3339)"
+// @Param sent_from query string false "Sent at or after (RFC3339)"
+// @Param sent_to query string false "Sent before (RFC3339)"
+// @Param limit query int false "Page size" default(100)
+// @Param cursor query string false "Cursor returned as meta.next_cursor by the previous page"
+// @Success 200 {object} APIResult{meta=model.PageMeta}
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
 // @Router /messages [get]
 func (r *MessageHandler) Retrieve(w http.ResponseWriter, req *http.Request) {
 	ctx, cancel := context.WithCancel(req.Context())
 	defer cancel()
 
-	status := req.URL.Query().Get("status")
+	filter, err := parseMessageFilter(req.URL.Query())
+	if err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: err.Error(),
+		})
+		return
+	}
 
-	messages, err := r.service.Retrieve(ctx, status)
+	messages, nextCursor, err := r.service.Retrieve(ctx, filter)
 	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			writeJSONResponse(w, http.StatusBadRequest, APIError{
+				Code:    "VALIDATION_ERROR",
+				Message: validationErr.Error(),
+			})
+			return
+		}
+
 		writeJSONResponse(w, http.StatusInternalServerError, APIError{
 			Message: "Failed to fetch messages",
 		})
@@ -71,6 +186,210 @@ func (r *MessageHandler) Retrieve(w http.ResponseWriter, req *http.Request) {
 	}
 
 	writeJSONResponse(w, http.StatusOK, APIResult{
+		Meta: model.PageMeta{NextCursor: nextCursor},
 		Data: messages,
 	})
 }
+
+func parseMessageFilter(query url.Values) (model.MessageFilter, error) {
+	filter := model.MessageFilter{
+		Status: query.Get("status"),
+		Phone:  query.Get("phone"),
+		Cursor: query.Get("cursor"),
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value <= 0 {
+			return model.MessageFilter{}, errors.New("limit: must be a positive integer")
+		}
+		filter.Limit = value
+	}
+
+	times := []struct {
+		name   string
+		target *time.Time
+	}{
+		{"created_from", &filter.CreatedFrom},
+		{"created_to", &filter.CreatedTo},
+		{"sent_from", &filter.SentFrom},
+		{"sent_to", &filter.SentTo},
+	}
+	for _, t := range times {
+		value := query.Get(t.name)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return model.MessageFilter{}, fmt.Errorf("%s: must be an RFC3339 timestamp", t.name)
+		}
+		*t.target = parsed
+	}
+
+	return filter, nil
+}
+
+// Get fetches a single message
+// @Summary Retrieve message
+// @Description Fetches a message with the provider message id and the time it was sent.
+// @Tags Message
+// @Produce json
+// @Param id path int true "Message ID"
+// @Success 200 {object} APIResult{data=model.MessageDetail}
+// @Failure 400 {object} APIError
+// @Failure 404 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /messages/{id} [get]
+func (r *MessageHandler) Get(w http.ResponseWriter, req *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(req, "id"), 10, 64)
+	if err != nil || id == 0 {
+		writeJSONResponse(w, http.StatusBadRequest, APIError{
+			Message: "Invalid message id",
+		})
+		return
+	}
+
+	message, err := r.service.Get(req.Context(), uint(id))
+	if errors.Is(err, service.ErrMessageNotFound) {
+		writeJSONResponse(w, http.StatusNotFound, APIError{
+			Code:    "NOT_FOUND",
+			Message: "Message not found",
+		})
+		return
+	}
+	if err != nil {
+		writeJSONResponse(w, http.StatusInternalServerError, APIError{
+			Message: "Failed to fetch message",
+		})
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, APIResult{
+		Data: message,
+	})
+}
+
+// GetByProviderMessageID fetches a message by its provider message id
+// @Summary Retrieve message by provider message id
+// @Description Fetches a message by the provider that accepted it and the message id that provider returned.
+// @Tags Message
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param messageId path string true "Provider message ID"
+// @Success 200 {object} APIResult{data=model.MessageDetail}
+// @Failure 404 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /messages/provider/{provider}/{messageId} [get]
+func (r *MessageHandler) GetByProviderMessageID(w http.ResponseWriter, req *http.Request) {
+	message, err := r.service.GetByProviderMessageID(req.Context(), chi.URLParam(req, "provider"), chi.URLParam(req, "messageId"))
+	if errors.Is(err, service.ErrMessageNotFound) {
+		writeJSONResponse(w, http.StatusNotFound, APIError{
+			Code:    "NOT_FOUND",
+			Message: "Message not found",
+		})
+		return
+	}
+	if err != nil {
+		writeJSONResponse(w, http.StatusInternalServerError, APIError{
+			Message: "Failed to fetch message",
+		})
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, APIResult{
+		Data: message,
+	})
+}
+
+// Create enqueues a new message
+// @Summary Create message
+// @Description Validates and stores a new message with pending status.
+// @Tags Message
+// @Accept json
+// @Produce json
+// @Param message body model.MessageRequest true "Message to enqueue"
+// @Success 201 {object} APIResult
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /messages [post]
+func (r *MessageHandler) Create(w http.ResponseWriter, req *http.Request) {
+	var body model.MessageRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, APIError{
+			Message: "Invalid request body",
+		})
+		return
+	}
+
+	message, err := r.service.Create(req.Context(), body)
+	if err != nil {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			writeJSONResponse(w, http.StatusBadRequest, APIError{
+				Code:    "VALIDATION_ERROR",
+				Message: validationErr.Error(),
+			})
+			return
+		}
+
+		writeJSONResponse(w, http.StatusInternalServerError, APIError{
+			Message: "Failed to create message",
+		})
+		return
+	}
+
+	writeJSONResponse(w, http.StatusCreated, APIResult{
+		Data: message,
+	})
+}
+
+// Import enqueues messages in bulk
+// @Summary Bulk import messages
+// @Description Streams a CSV (with to,content header) or NDJSON body, stores valid rows and reports rejected ones.
+// @Tags Message
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Success 200 {object} APIResult{data=model.ImportReport}
+// @Failure 400 {object} APIError
+// @Failure 415 {object} APIError
+// @Router /messages/bulk [post]
+func (r *MessageHandler) Import(w http.ResponseWriter, req *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+
+	var format string
+	switch mediaType {
+	case "text/csv":
+		format = service.ImportFormatCSV
+	case "application/x-ndjson", "application/ndjson":
+		format = service.ImportFormatNDJSON
+	default:
+		writeJSONResponse(w, http.StatusUnsupportedMediaType, APIError{
+			Message: "Content-Type must be text/csv or application/x-ndjson",
+		})
+		return
+	}
+
+	report, err := r.service.Import(req.Context(), req.Body, format)
+	if err != nil && report.Total == 0 {
+		writeJSONResponse(w, http.StatusBadRequest, APIError{
+			Message: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		// rows read before the stream broke are already stored, so report them
+		writeJSONResponse(w, http.StatusBadRequest, APIResult{
+			Code:    "IMPORT_ABORTED",
+			Message: err.Error(),
+			Data:    report,
+		})
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, APIResult{
+		Data: report,
+	})
+}
@@ -4,8 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/busragumusel/insider-case/internal/entity"
+	"github.com/busragumusel/insider-case/internal/model"
+	"github.com/busragumusel/insider-case/internal/service"
+	"github.com/go-chi/chi/v5"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,7 +23,20 @@ func (m *mockMessageService) StartProcess(_ context.Context) {}
 
 func (m *mockMessageService) StopProcess() {}
 
-func (m *mockMessageService) Retrieve(_ context.Context, status string) ([]entity.Message, error) {
+func (m *mockMessageService) RequestStart(_ context.Context, changedBy string) (model.ProcessingState, error) {
+	return model.ProcessingState{State: service.StateRunning, ChangedBy: changedBy}, nil
+}
+
+func (m *mockMessageService) RequestStop(_ context.Context, changedBy string) (model.ProcessingState, error) {
+	return model.ProcessingState{State: service.StateStopped, ChangedBy: changedBy}, nil
+}
+
+func (m *mockMessageService) Retrieve(_ context.Context, filter model.MessageFilter) ([]entity.Message, string, error) {
+	if filter.Limit > 1000 {
+		return nil, "", &service.ValidationError{Field: "limit", Message: "limit must be at most 1000"}
+	}
+
+	status := filter.Status
 	return []entity.Message{
 		{
 			ID:          1,
@@ -36,9 +54,93 @@ func (m *mockMessageService) Retrieve(_ context.Context, status string) ([]entit
 			CreatedAt:   time.Now().Add(-5 * time.Minute),
 			SentAt:      time.Now(),
 		},
+	}, "next", nil
+}
+
+func (m *mockMessageService) Create(_ context.Context, req model.MessageRequest) (entity.Message, error) {
+	if req.To == "" {
+		return entity.Message{}, &service.ValidationError{Field: "to", Message: "phone number must be in E.164 format"}
+	}
+
+	return entity.Message{
+		ID:          3,
+		PhoneNumber: req.To,
+		Content:     req.Content,
+		Status:      entity.StatusPending,
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+func (m *mockMessageService) Import(_ context.Context, _ io.Reader, format string) (model.ImportReport, error) {
+	return model.ImportReport{Total: 1, Imported: 1, Errors: []model.ImportError{}}, nil
+}
+
+func (m *mockMessageService) Get(_ context.Context, id uint) (model.MessageDetail, error) {
+	if id != 1 {
+		return model.MessageDetail{}, service.ErrMessageNotFound
+	}
+
+	return model.MessageDetail{
+		Message:           entity.Message{ID: 1, PhoneNumber: "+905551111111", Content: "Test", Status: entity.StatusSent},
+		ProviderMessageID: "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849",
+		SendingTime:       "2025-01-01T10:00:00Z",
+	}, nil
+}
+
+func (m *mockMessageService) Rate() (time.Duration, int) {
+	return 2 * time.Minute, 2
+}
+
+func (m *mockMessageService) SetRate(_ context.Context, interval time.Duration, batchSize int) error {
+	if interval < time.Second {
+		return &service.ValidationError{Field: "interval", Message: "interval must be at least 1s"}
+	}
+
+	return nil
+}
+
+func (m *mockMessageService) ProcessNow(_ context.Context) ([]model.ProcessResult, error) {
+	return []model.ProcessResult{
+		{ID: 1, Status: entity.StatusSent, ProviderMessageID: "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849"},
+		{ID: 2, Status: entity.StatusPending, Error: "failed to send request: 503 Service Unavailable"},
 	}, nil
 }
 
+func (m *mockMessageService) Status() model.ProcessStatus {
+	return model.ProcessStatus{Running: true, WorkerID: "worker-1", TotalSent: 4}
+}
+
+func (m *mockMessageService) GetByProviderMessageID(
+	_ context.Context,
+	providerName string,
+	providerMessageID string,
+) (model.MessageDetail, error) {
+	if providerName != "webhook" || providerMessageID != "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849" {
+		return model.MessageDetail{}, service.ErrMessageNotFound
+	}
+
+	return m.Get(context.Background(), 1)
+}
+
+func (m *mockMessageService) RecordDelivery(_ context.Context, report model.DeliveryReport) (model.MessageDetail, error) {
+	if report.Status != "delivered" {
+		return model.MessageDetail{}, &service.ValidationError{Field: "status", Message: "status must be a known delivery status"}
+	}
+	if report.Provider != "webhook" || report.MessageID != "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849" {
+		return model.MessageDetail{}, service.ErrMessageNotFound
+	}
+
+	detail, _ := m.Get(context.Background(), 1)
+	detail.Status = entity.StatusDelivered
+	return detail, nil
+}
+
+func withURLParam(req *http.Request, key, value string) *http.Request {
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add(key, value)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+}
+
 func TestStartProcess(t *testing.T) {
 	service := &mockMessageService{}
 	handler := NewMessageHandler(service)
@@ -58,11 +160,19 @@ func TestStopProcess(t *testing.T) {
 
 	req, err := http.NewRequest("GET", "/stop", nil)
 	assert.NoError(t, err)
+	req.Header.Set("X-Operator", "alice")
 
 	rr := httptest.NewRecorder()
 	handler.StopProcess(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Data model.ProcessingState `json:"data"`
+	}
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	assert.Equal(t, "stopped", response.Data.State)
+	assert.Equal(t, "alice", response.Data.ChangedBy)
 }
 
 func TestRetrieve(t *testing.T) {
@@ -82,3 +192,232 @@ func TestRetrieve(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Contains(t, response, "data")
 }
+
+func TestCreate(t *testing.T) {
+	service := &mockMessageService{}
+	handler := NewMessageHandler(service)
+
+	body := `{"to":"+905551111111","content":"Hello"}`
+	req, err := http.NewRequest("POST", "/messages", strings.NewReader(body))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.Create(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	var response map[string]interface{}
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Contains(t, response, "data")
+}
+
+func TestCreateValidationError(t *testing.T) {
+	service := &mockMessageService{}
+	handler := NewMessageHandler(service)
+
+	body := `{"to":"","content":"Hello"}`
+	req, err := http.NewRequest("POST", "/messages", strings.NewReader(body))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.Create(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestCreateInvalidBody(t *testing.T) {
+	service := &mockMessageService{}
+	handler := NewMessageHandler(service)
+
+	req, err := http.NewRequest("POST", "/messages", strings.NewReader("{"))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.Create(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestImport(t *testing.T) {
+	service := &mockMessageService{}
+	handler := NewMessageHandler(service)
+
+	body := "to,content\n+905551111111,Hello\n"
+	req, err := http.NewRequest("POST", "/messages/bulk", strings.NewReader(body))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+
+	rr := httptest.NewRecorder()
+	handler.Import(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestImportUnsupportedContentType(t *testing.T) {
+	service := &mockMessageService{}
+	handler := NewMessageHandler(service)
+
+	req, err := http.NewRequest("POST", "/messages/bulk", strings.NewReader("{}"))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	handler.Import(rr, req)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+}
+
+func TestRetrieveWithFilters(t *testing.T) {
+	service := &mockMessageService{}
+	handler := NewMessageHandler(service)
+
+	req, err := http.NewRequest("GET", "/messages?status=sent&limit=2&created_from=2025-01-01T00:00:00Z", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.Retrieve(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Meta model.PageMeta `json:"meta"`
+	}
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "next", response.Meta.NextCursor)
+}
+
+func TestRetrieveInvalidParams(t *testing.T) {
+	service := &mockMessageService{}
+	handler := NewMessageHandler(service)
+
+	for _, query := range []string{"limit=abc", "sent_to=yesterday", "limit=5000"} {
+		req, err := http.NewRequest("GET", "/messages?"+query, nil)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		handler.Retrieve(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+}
+
+func TestGet(t *testing.T) {
+	service := &mockMessageService{}
+	handler := NewMessageHandler(service)
+
+	req, err := http.NewRequest("GET", "/messages/1", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.Get(rr, withURLParam(req, "id", "1"))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849", response.Data["messageId"])
+	assert.Equal(t, float64(1), response.Data["ID"])
+}
+
+func TestGetNotFound(t *testing.T) {
+	service := &mockMessageService{}
+	handler := NewMessageHandler(service)
+
+	req, err := http.NewRequest("GET", "/messages/2", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.Get(rr, withURLParam(req, "id", "2"))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestGetInvalidID(t *testing.T) {
+	service := &mockMessageService{}
+	handler := NewMessageHandler(service)
+
+	req, err := http.NewRequest("GET", "/messages/abc", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.Get(rr, withURLParam(req, "id", "abc"))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestProcessNow(t *testing.T) {
+	service := &mockMessageService{}
+	handler := NewMessageHandler(service)
+
+	req, err := http.NewRequest("POST", "/process-now", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ProcessNow(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Data []model.ProcessResult `json:"data"`
+	}
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Data, 2)
+	assert.Equal(t, entity.StatusSent, response.Data[0].Status)
+}
+
+func TestStatus(t *testing.T) {
+	service := &mockMessageService{}
+	handler := NewMessageHandler(service)
+
+	req, err := http.NewRequest("GET", "/status", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.Status(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Data model.ProcessStatus `json:"data"`
+	}
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.True(t, response.Data.Running)
+	assert.Equal(t, 4, response.Data.TotalSent)
+}
+
+func TestGetByProviderMessageID(t *testing.T) {
+	service := &mockMessageService{}
+	handler := NewMessageHandler(service)
+
+	tests := []struct {
+		provider  string
+		messageID string
+		status    int
+	}{
+		{"webhook", "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849", http.StatusOK},
+		{"backup", "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849", http.StatusNotFound},
+		{"webhook", "unknown", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest("GET", "/messages/provider/"+tt.provider+"/"+tt.messageID, nil)
+		assert.NoError(t, err)
+
+		routeCtx := chi.NewRouteContext()
+		routeCtx.URLParams.Add("provider", tt.provider)
+		routeCtx.URLParams.Add("messageId", tt.messageID)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+
+		rr := httptest.NewRecorder()
+		handler.GetByProviderMessageID(rr, req)
+
+		assert.Equal(t, tt.status, rr.Code, tt.provider)
+	}
+}
@@ -0,0 +1,174 @@
+package leader
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"log"
+	"sync"
+	"time"
+)
+
+const DefaultTTL = 15 * time.Second
+
+// renewScript extends the lock only if this instance still holds it, so an
+// instance that lost the lock can't extend someone else's.
+var renewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript deletes the lock only if this instance holds it.
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Elector competes for a lock in Redis. The instance holding it is the leader
+// and keeps renewing it; when the leader dies the lock expires after ttl and
+// another instance takes it over.
+type Elector struct {
+	client *redis.Client
+	key    string
+	id     string
+	ttl    time.Duration
+
+	mu       sync.Mutex
+	isLeader bool
+	leaderID string
+}
+
+func NewElector(client *redis.Client, key, id string, ttl time.Duration) *Elector {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	return &Elector{
+		client: client,
+		key:    key,
+		id:     id,
+		ttl:    ttl,
+	}
+}
+
+// IsLeader reports whether this instance held the lock when it last checked.
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.isLeader
+}
+
+// LeaderID returns the id of the instance that held the lock when this one
+// last checked, empty if nobody did.
+func (e *Elector) LeaderID() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.leaderID
+}
+
+// Run campaigns for leadership until ctx is done and then gives the lock up,
+// so that another instance doesn't have to wait for it to expire.
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		e.Campaign(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			e.resign()
+			return
+		}
+	}
+}
+
+// Campaign competes for leadership once. Run does so periodically; calling it
+// before the first run settles who leads before anything depends on it.
+func (e *Elector) Campaign(ctx context.Context) {
+	if e.IsLeader() {
+		renewed, err := renewScript.Run(ctx, e.client, []string{e.key}, e.id, e.ttl.Milliseconds()).Int64()
+		if err == nil && renewed == 1 {
+			return
+		}
+
+		// without a renewal the lock may expire any moment, so stop leading
+		log.Printf("Lost leadership: %s.", e.lossReason(ctx, err))
+		e.setLeader(false, "")
+	}
+
+	acquired, err := e.client.SetNX(ctx, e.key, e.id, e.ttl).Result()
+	if err != nil {
+		log.Printf("Failed to campaign for leadership: %v", err)
+		e.setLeader(false, "")
+		return
+	}
+	if acquired {
+		log.Printf("Became leader as %s.", e.id)
+		e.setLeader(true, e.id)
+		return
+	}
+
+	leaderID, err := e.client.Get(ctx, e.key).Result()
+	if err != nil && err != redis.Nil {
+		log.Printf("Failed to read leader: %v", err)
+	}
+
+	// the lock is still ours, e.g. after a renewal that failed on the way or a
+	// restart under the same id, so lead on instead of leaving the cluster
+	// without a leader until it expires
+	if leaderID == e.id {
+		renewed, err := renewScript.Run(ctx, e.client, []string{e.key}, e.id, e.ttl.Milliseconds()).Int64()
+		if err == nil && renewed == 1 {
+			log.Printf("Resumed leadership as %s.", e.id)
+			e.setLeader(true, e.id)
+			return
+		}
+	}
+	e.setLeader(false, leaderID)
+}
+
+// lossReason explains why renewing the lock failed.
+func (e *Elector) lossReason(ctx context.Context, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+
+	holder, err := e.client.Get(ctx, e.key).Result()
+	if err == redis.Nil {
+		return "lock expired"
+	}
+	if err != nil {
+		return "lock not renewed, failed to read its holder: " + err.Error()
+	}
+
+	return "lock held by " + holder
+}
+
+func (e *Elector) resign() {
+	if !e.IsLeader() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := releaseScript.Run(ctx, e.client, []string{e.key}, e.id).Err(); err != nil {
+		log.Printf("Failed to give up leadership: %v", err)
+	}
+	e.setLeader(false, "")
+}
+
+func (e *Elector) setLeader(isLeader bool, leaderID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.isLeader = isLeader
+	e.leaderID = leaderID
+}
@@ -0,0 +1,100 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRedisClient(t *testing.T) *redis.Client {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Skip("redis is not available:", err)
+	}
+
+	return client
+}
+
+func TestElectorTakesOverWhenLeaderStops(t *testing.T) {
+	client := setupRedisClient(t)
+	key := "test_leader:" + time.Now().Format(time.RFC3339Nano)
+	t.Cleanup(func() { client.Del(context.Background(), key) })
+
+	first := NewElector(client, key, "first", 300*time.Millisecond)
+	second := NewElector(client, key, "second", 300*time.Millisecond)
+
+	firstCtx, stopFirst := context.WithCancel(context.Background())
+	go first.Run(firstCtx)
+	assert.Eventually(t, first.IsLeader, time.Second, 10*time.Millisecond)
+
+	secondCtx, stopSecond := context.WithCancel(context.Background())
+	defer stopSecond()
+	go second.Run(secondCtx)
+
+	// the leader keeps renewing the lock for longer than its ttl
+	time.Sleep(500 * time.Millisecond)
+	assert.True(t, first.IsLeader())
+	assert.False(t, second.IsLeader())
+	assert.Equal(t, "first", second.LeaderID())
+
+	stopFirst()
+	assert.Eventually(t, second.IsLeader, time.Second, 10*time.Millisecond)
+	assert.False(t, first.IsLeader())
+}
+
+func TestElectorStepsDownWhenLockIsLost(t *testing.T) {
+	client := setupRedisClient(t)
+	key := "test_leader:" + time.Now().Format(time.RFC3339Nano)
+	t.Cleanup(func() { client.Del(context.Background(), key) })
+
+	elector := NewElector(client, key, "first", time.Minute)
+	ctx := context.Background()
+
+	elector.Campaign(ctx)
+	assert.True(t, elector.IsLeader())
+
+	client.Set(ctx, key, "other", time.Minute)
+
+	elector.Campaign(ctx)
+	assert.False(t, elector.IsLeader())
+	assert.Equal(t, "other", elector.LeaderID())
+}
+
+func TestElectorResumesLeadershipWhileLockIsHeld(t *testing.T) {
+	client := setupRedisClient(t)
+	key := "test_leader:" + time.Now().Format(time.RFC3339Nano)
+	t.Cleanup(func() { client.Del(context.Background(), key) })
+
+	elector := NewElector(client, key, "first", time.Minute)
+	ctx := context.Background()
+
+	elector.Campaign(ctx)
+	assert.True(t, elector.IsLeader())
+
+	// as if the last renewal had failed while the lock stayed in place
+	elector.setLeader(false, "")
+
+	elector.Campaign(ctx)
+	assert.True(t, elector.IsLeader())
+	assert.Equal(t, "first", elector.LeaderID())
+}
+
+func TestLossReason(t *testing.T) {
+	client := setupRedisClient(t)
+	key := "test_leader:" + time.Now().Format(time.RFC3339Nano)
+	t.Cleanup(func() { client.Del(context.Background(), key) })
+
+	elector := NewElector(client, key, "first", time.Minute)
+	ctx := context.Background()
+
+	assert.Equal(t, "lock expired", elector.lossReason(ctx, nil))
+
+	client.Set(ctx, key, "other", time.Minute)
+	assert.Equal(t, "lock held by other", elector.lossReason(ctx, nil))
+
+	assert.Equal(t, "connection refused", elector.lossReason(ctx, errors.New("connection refused")))
+}
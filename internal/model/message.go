@@ -0,0 +1,127 @@
+package model
+
+import (
+	"github.com/busragumusel/insider-case/internal/entity"
+	"time"
+)
+
+type MessageRequest struct {
+	To      string `json:"to"`
+	Content string `json:"content"`
+}
+
+type ImportReport struct {
+	Total    int           `json:"total"`
+	Imported int           `json:"imported"`
+	Rejected int           `json:"rejected"`
+	Errors   []ImportError `json:"errors"`
+}
+
+type ImportError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+type MessageFilter struct {
+	Status      string
+	Phone       string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	SentFrom    time.Time
+	SentTo      time.Time
+	Limit       int
+	Cursor      string
+}
+
+// Cursor is the keyset position of the last message on a page.
+type Cursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        uint      `json:"i"`
+}
+
+type PageMeta struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type MessageDetail struct {
+	entity.Message
+	ProviderMessageID string `json:"messageId,omitempty"`
+	SendingTime       string `json:"sendingTime,omitempty"`
+}
+
+type RateConfig struct {
+	Interval  string `json:"interval" example:"2m0s"`
+	BatchSize int    `json:"batch_size" example:"2"`
+}
+
+type ProcessResult struct {
+	ID                uint   `json:"id"`
+	Status            string `json:"status"`
+	Provider          string `json:"provider,omitempty"`
+	ProviderMessageID string `json:"messageId,omitempty"`
+	Error             string `json:"error,omitempty"`
+	// Released is set when the message went back to the queue without being
+	// attempted, e.g. because its provider was rate limiting or unavailable.
+	Released bool `json:"released,omitempty"`
+}
+
+type ProcessStatus struct {
+	Running       bool       `json:"running"`
+	WorkerID      string     `json:"worker_id"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty"`
+	LastRunResult string     `json:"last_run_result,omitempty"`
+	LastRunError  string     `json:"last_run_error,omitempty"`
+	NextRunAt     *time.Time `json:"next_run_at,omitempty"`
+	LastRunSent   int        `json:"last_run_sent"`
+	LastRunFailed int        `json:"last_run_failed"`
+	TotalSent     int        `json:"total_sent"`
+	TotalFailed   int        `json:"total_failed"`
+
+	// Leader is whether this instance runs the schedule, LeaderID the
+	// worker id of the instance that does.
+	Leader   bool   `json:"leader"`
+	LeaderID string `json:"leader_id,omitempty"`
+
+	// Interval and BatchSize are the configured rate, EffectiveBatchSize is
+	// what is actually sent per run while providers rate limit us.
+	Interval           string     `json:"interval"`
+	BatchSize          int        `json:"batch_size"`
+	EffectiveBatchSize int        `json:"effective_batch_size"`
+	ThrottledUntil     *time.Time `json:"throttled_until,omitempty"`
+
+	// DesiredState is the state requested for all instances with /start and
+	// /stop, Running whether this instance has caught up with it.
+	DesiredState ProcessingState `json:"desired_state"`
+
+	Providers []ProviderStatus `json:"providers"`
+}
+
+// ProcessingState is whether processing should run on all instances, and who
+// asked for it when.
+type ProcessingState struct {
+	State     string     `json:"state"`
+	ChangedBy string     `json:"changed_by,omitempty"`
+	ChangedAt *time.Time `json:"changed_at,omitempty"`
+}
+
+type ProviderStatus struct {
+	Name                string     `json:"name"`
+	Type                string     `json:"type"`
+	CircuitState        string     `json:"circuit_state,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures,omitempty"`
+	OpenUntil           *time.Time `json:"open_until,omitempty"`
+}
+
+// DeliveryReport is a delivery receipt sent by a provider for a message it accepted.
+type DeliveryReport struct {
+	// Provider is the provider the callback was authenticated as, the
+	// message ids it reports are only unique among its own.
+	Provider string `json:"-"`
+
+	MessageID   string     `json:"messageId"`
+	Status      string     `json:"status"`
+	DeliveredAt *time.Time `json:"deliveredAt,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
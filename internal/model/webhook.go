@@ -3,6 +3,8 @@ package model
 type Response struct {
 	MessageID string `json:"messageId"`
 	Message   string `json:"message"`
+	// Raw is the response as the provider sent it.
+	Raw string `json:"-"`
 }
 
 type Payload struct {
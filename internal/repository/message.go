@@ -2,17 +2,44 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"github.com/busragumusel/insider-case/internal/entity"
+	"github.com/busragumusel/insider-case/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
 )
 
+// ErrClaimLost means the message is no longer claimed by the worker that
+// tried to record the outcome of sending it.
+var ErrClaimLost = errors.New("message is no longer claimed by this worker")
+
 type MessageRepository struct {
 	DB *gorm.DB
 }
 
 type MessageRepo interface {
 	GetByStatus(ctx context.Context, status string, limit int) ([]entity.Message, error)
+	List(ctx context.Context, filter model.MessageFilter, after *model.Cursor) ([]entity.Message, error)
+	GetByID(ctx context.Context, id uint) (entity.Message, error)
+	ClaimPending(ctx context.Context, limit int, workerID string) ([]entity.Message, error)
+	ReleaseExpiredClaims(ctx context.Context, claimedBefore time.Time, maxAttempts int) (int64, error)
 	Update(ctx context.Context, id uint, status string) error
+	MarkSent(ctx context.Context, id uint, workerID string, providerName string, response model.Response) error
+	GetByProviderMessageID(ctx context.Context, providerName string, providerMessageID string) (entity.Message, error)
+	RecordDelivery(
+		ctx context.Context,
+		providerName string,
+		providerMessageID string,
+		status string,
+		deliveredAt time.Time,
+		reason string,
+	) (int64, error)
+	RenewClaim(ctx context.Context, id uint, workerID string) error
+	Release(ctx context.Context, id uint, workerID string) error
+	RecordFailure(ctx context.Context, id uint, workerID string, status string, lastError string, nextAttemptAt time.Time) error
+	Create(ctx context.Context, message *entity.Message) error
+	CreateBatch(ctx context.Context, messages []entity.Message) error
 }
 
 func NewMessageRepository(DB *gorm.DB) *MessageRepository {
@@ -40,6 +67,138 @@ func (r *MessageRepository) GetByStatus(
 	return messages, err
 }
 
+// ClaimPending locks up to limit due pending messages, skipping rows another
+// worker already holds, and moves them to processing in the same transaction
+// so that no other instance can pick them up.
+func (r *MessageRepository) ClaimPending(ctx context.Context, limit int, workerID string) ([]entity.Message, error) {
+	var messages []entity.Message
+
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", entity.StatusPending).
+			Where("next_attempt_at IS NULL OR next_attempt_at <= NOW()").
+			Order("created_at ASC").
+			Limit(limit).
+			Find(&messages).Error
+		if err != nil || len(messages) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(messages))
+		for i, message := range messages {
+			ids[i] = message.ID
+		}
+
+		return tx.Model(&entity.Message{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"status":     entity.StatusProcessing,
+				"claimed_by": workerID,
+				"claimed_at": gorm.Expr("NOW()"),
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claimedAt := time.Now()
+	for i := range messages {
+		messages[i].Status = entity.StatusProcessing
+		messages[i].ClaimedBy = workerID
+		messages[i].ClaimedAt = claimedAt
+	}
+
+	return messages, nil
+}
+
+// ReleaseExpiredClaims returns messages whose worker didn't finish them before
+// claimedBefore back to pending. The lost claim counts as an attempt, so a
+// message that keeps crashing its worker ends up failed after maxAttempts.
+func (r *MessageRepository) ReleaseExpiredClaims(
+	ctx context.Context,
+	claimedBefore time.Time,
+	maxAttempts int,
+) (int64, error) {
+	result := r.DB.WithContext(ctx).
+		Model(&entity.Message{}).
+		Where("status = ?", entity.StatusProcessing).
+		Where("claimed_at < ?", claimedBefore).
+		Updates(map[string]interface{}{
+			"status": gorm.Expr(
+				"CASE WHEN attempt_count + 1 >= ? THEN ? ELSE ? END",
+				maxAttempts, entity.StatusFailed, entity.StatusPending,
+			),
+			"attempt_count":   gorm.Expr("attempt_count + 1"),
+			"last_error":      "claim expired",
+			"next_attempt_at": nil,
+			"claimed_by":      "",
+			"claimed_at":      nil,
+		})
+
+	return result.RowsAffected, result.Error
+}
+
+func (r *MessageRepository) List(
+	ctx context.Context,
+	filter model.MessageFilter,
+	after *model.Cursor,
+) ([]entity.Message, error) {
+	var messages []entity.Message
+
+	db := r.DB.WithContext(ctx)
+
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+	if filter.Phone != "" {
+		db = db.Where("phone_number = ?", filter.Phone)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		db = db.Where("created_at >= ?", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		db = db.Where("created_at < ?", filter.CreatedTo)
+	}
+	if !filter.SentFrom.IsZero() {
+		db = db.Where("sent_at >= ?", filter.SentFrom)
+	}
+	if !filter.SentTo.IsZero() {
+		db = db.Where("sent_at < ?", filter.SentTo)
+	}
+	if after != nil {
+		db = db.Where("(created_at, id) > (?, ?)", after.CreatedAt, after.ID)
+	}
+
+	err := db.
+		Order("created_at ASC").
+		Order("id ASC").
+		Limit(filter.Limit).
+		Find(&messages).Error
+
+	return messages, err
+}
+
+func (r *MessageRepository) GetByID(ctx context.Context, id uint) (entity.Message, error) {
+	var message entity.Message
+	err := r.DB.WithContext(ctx).First(&message, id).Error
+	return message, err
+}
+
+// GetByProviderMessageID looks a message up by the provider that accepted it
+// and the id that provider assigned.
+func (r *MessageRepository) GetByProviderMessageID(
+	ctx context.Context,
+	providerName string,
+	providerMessageID string,
+) (entity.Message, error) {
+	var message entity.Message
+	err := r.DB.WithContext(ctx).
+		Where("provider_name = ? AND provider_message_id = ?", providerName, providerMessageID).
+		First(&message).Error
+	return message, err
+}
+
 func (r *MessageRepository) Update(ctx context.Context, id uint, status string) error {
 	err := r.DB.WithContext(ctx).
 		Model(&entity.Message{}).
@@ -50,3 +209,140 @@ func (r *MessageRepository) Update(ctx context.Context, id uint, status string)
 		}).Error
 	return err
 }
+
+func (r *MessageRepository) Create(ctx context.Context, message *entity.Message) error {
+	return r.DB.WithContext(ctx).Create(message).Error
+}
+
+func (r *MessageRepository) CreateBatch(ctx context.Context, messages []entity.Message) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&messages).Error
+	})
+}
+
+// MarkSent marks the message claimed by workerID as sent and records the
+// provider that accepted it along with the provider's response.
+func (r *MessageRepository) MarkSent(
+	ctx context.Context,
+	id uint,
+	workerID string,
+	providerName string,
+	response model.Response,
+) error {
+	result := r.DB.WithContext(ctx).
+		Model(&entity.Message{}).
+		Scopes(claimedBy(id, workerID)).
+		Updates(map[string]interface{}{
+			"status":              entity.StatusSent,
+			"sent_at":             gorm.Expr("NOW()"),
+			"provider_name":       providerName,
+			"provider_message_id": response.MessageID,
+			"provider_response":   response.Raw,
+		})
+
+	return claimResult(result)
+}
+
+// RecordDelivery moves a sent message to its final delivery status. Messages
+// that already have one are left alone, so a duplicate report updates no rows.
+// A zero deliveredAt leaves delivered_at empty.
+func (r *MessageRepository) RecordDelivery(
+	ctx context.Context,
+	providerName string,
+	providerMessageID string,
+	status string,
+	deliveredAt time.Time,
+	reason string,
+) (int64, error) {
+	var delivered interface{}
+	if !deliveredAt.IsZero() {
+		delivered = deliveredAt
+	}
+
+	result := r.DB.WithContext(ctx).
+		Model(&entity.Message{}).
+		Where("provider_name = ? AND provider_message_id = ?", providerName, providerMessageID).
+		Where("status = ?", entity.StatusSent).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"delivered_at": delivered,
+			"last_error":   reason,
+		})
+
+	return result.RowsAffected, result.Error
+}
+
+// RenewClaim restarts the visibility timeout of a message claimed by
+// workerID, so that it isn't released while it is being sent.
+func (r *MessageRepository) RenewClaim(ctx context.Context, id uint, workerID string) error {
+	result := r.DB.WithContext(ctx).
+		Model(&entity.Message{}).
+		Scopes(claimedBy(id, workerID)).
+		Update("claimed_at", gorm.Expr("NOW()"))
+
+	return claimResult(result)
+}
+
+// Release returns a message claimed by workerID to pending without counting
+// an attempt.
+func (r *MessageRepository) Release(ctx context.Context, id uint, workerID string) error {
+	result := r.DB.WithContext(ctx).
+		Model(&entity.Message{}).
+		Scopes(claimedBy(id, workerID)).
+		Updates(map[string]interface{}{
+			"status":     entity.StatusPending,
+			"claimed_by": "",
+			"claimed_at": nil,
+		})
+
+	return claimResult(result)
+}
+
+// RecordFailure counts a failed send attempt of a message claimed by
+// workerID. A zero nextAttemptAt clears the scheduled retry, which is what
+// terminal statuses want.
+func (r *MessageRepository) RecordFailure(
+	ctx context.Context,
+	id uint,
+	workerID string,
+	status string,
+	lastError string,
+	nextAttemptAt time.Time,
+) error {
+	var next interface{}
+	if !nextAttemptAt.IsZero() {
+		next = nextAttemptAt
+	}
+
+	result := r.DB.WithContext(ctx).
+		Model(&entity.Message{}).
+		Scopes(claimedBy(id, workerID)).
+		Updates(map[string]interface{}{
+			"status":          status,
+			"attempt_count":   gorm.Expr("attempt_count + 1"),
+			"last_error":      lastError,
+			"next_attempt_at": next,
+		})
+
+	return claimResult(result)
+}
+
+// claimedBy limits an update to the message while workerID still holds its
+// claim. Once the claim expired and the message was released, or claimed by
+// another worker, the outcome of this worker's attempt no longer counts.
+func claimedBy(id uint, workerID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ? AND status = ? AND claimed_by = ?", id, entity.StatusProcessing, workerID)
+	}
+}
+
+func claimResult(result *gorm.DB) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrClaimLost
+	}
+
+	return nil
+}
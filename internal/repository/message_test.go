@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/busragumusel/insider-case/internal/entity"
+	"github.com/busragumusel/insider-case/internal/model"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -48,7 +49,7 @@ func setupTestDB() *gorm.DB {
 		panic("failed to connect to test database")
 	}
 
-	db.AutoMigrate(&entity.Message{})
+	db.AutoMigrate(&entity.Message{}, &entity.Setting{})
 
 	return db
 }
@@ -102,3 +103,342 @@ func TestUpdate(t *testing.T) {
 	assert.Equal(t, "sent", updatedMessage.Status)
 	assert.WithinDuration(t, time.Now(), updatedMessage.SentAt, 2*time.Second)
 }
+
+func TestCreate(t *testing.T) {
+	db := setupTestDB()
+	repo := NewMessageRepository(db)
+	ctx := context.Background()
+	db.Exec("DELETE FROM messages")
+
+	message := entity.Message{PhoneNumber: "+905551111111", Content: "Test", Status: entity.StatusPending}
+
+	err := repo.Create(ctx, &message)
+
+	var created entity.Message
+	db.First(&created, message.ID)
+
+	assert.NoError(t, err)
+	assert.NotZero(t, message.ID)
+	assert.Equal(t, entity.StatusPending, created.Status)
+	assert.Equal(t, "+905551111111", created.PhoneNumber)
+}
+
+func TestCreateBatch(t *testing.T) {
+	db := setupTestDB()
+	repo := NewMessageRepository(db)
+	ctx := context.Background()
+	db.Exec("DELETE FROM messages")
+
+	messages := []entity.Message{
+		{PhoneNumber: "+905551111111", Content: "First", Status: entity.StatusPending},
+		{PhoneNumber: "+905552222222", Content: "Second", Status: entity.StatusPending},
+	}
+
+	err := repo.CreateBatch(ctx, messages)
+
+	var count int64
+	db.Model(&entity.Message{}).Count(&count)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestList(t *testing.T) {
+	db := setupTestDB()
+	repo := NewMessageRepository(db)
+	ctx := context.Background()
+	db.Exec("DELETE FROM messages")
+
+	createdAt := time.Now().Add(-time.Hour).Truncate(time.Microsecond)
+	messages := []entity.Message{
+		{ID: 1, PhoneNumber: "+905551111111", Content: "First", Status: "sent", CreatedAt: createdAt},
+		{ID: 2, PhoneNumber: "+905552222222", Content: "Second", Status: "sent", CreatedAt: createdAt},
+		{ID: 3, PhoneNumber: "+905551111111", Content: "Third", Status: "sent", CreatedAt: createdAt.Add(time.Minute)},
+		{ID: 4, PhoneNumber: "+905551111111", Content: "Fourth", Status: "pending", CreatedAt: createdAt.Add(2 * time.Minute)},
+	}
+	db.Create(&messages)
+
+	result, err := repo.List(ctx, model.MessageFilter{Status: "sent", Limit: 10}, &model.Cursor{CreatedAt: createdAt, ID: 1})
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, uint(2), result[0].ID)
+	assert.Equal(t, uint(3), result[1].ID)
+
+	result, err = repo.List(ctx, model.MessageFilter{
+		Phone:       "+905551111111",
+		CreatedFrom: createdAt.Add(30 * time.Second),
+		Limit:       10,
+	}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, uint(3), result[0].ID)
+	assert.Equal(t, uint(4), result[1].ID)
+}
+
+func TestGetByID(t *testing.T) {
+	db := setupTestDB()
+	repo := NewMessageRepository(db)
+	ctx := context.Background()
+	db.Exec("DELETE FROM messages")
+
+	message := entity.Message{ID: 1, PhoneNumber: "+905551111111", Content: "Test", Status: "pending"}
+	db.Create(&message)
+
+	found, err := repo.GetByID(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Test", found.Content)
+
+	_, err = repo.GetByID(ctx, 2)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestClaimPending(t *testing.T) {
+	db := setupTestDB()
+	repo := NewMessageRepository(db)
+	ctx := context.Background()
+	db.Exec("DELETE FROM messages")
+
+	messages := []entity.Message{
+		{ID: 1, PhoneNumber: "+905551111111", Content: "New", Status: entity.StatusPending, CreatedAt: time.Now().Add(-3 * time.Minute)},
+		{ID: 2, PhoneNumber: "+905551111111", Content: "Retry due", Status: entity.StatusPending, CreatedAt: time.Now().Add(-2 * time.Minute), NextAttemptAt: time.Now().Add(-time.Minute)},
+		{ID: 3, PhoneNumber: "+905551111111", Content: "Retry later", Status: entity.StatusPending, NextAttemptAt: time.Now().Add(time.Hour)},
+		{ID: 4, PhoneNumber: "+905551111111", Content: "Failed", Status: entity.StatusFailed},
+	}
+	db.Create(&messages)
+
+	result, err := repo.ClaimPending(ctx, 10, "worker-1")
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, uint(1), result[0].ID)
+	assert.Equal(t, entity.StatusProcessing, result[0].Status)
+
+	var claimed entity.Message
+	db.First(&claimed, 2)
+	assert.Equal(t, entity.StatusProcessing, claimed.Status)
+	assert.Equal(t, "worker-1", claimed.ClaimedBy)
+
+	result, err = repo.ClaimPending(ctx, 10, "worker-2")
+
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func TestClaimPendingSkipsLockedRows(t *testing.T) {
+	db := setupTestDB()
+	repo := NewMessageRepository(db)
+	ctx := context.Background()
+	db.Exec("DELETE FROM messages")
+
+	messages := []entity.Message{
+		{ID: 1, PhoneNumber: "+905551111111", Content: "First", Status: entity.StatusPending, CreatedAt: time.Now().Add(-2 * time.Minute)},
+		{ID: 2, PhoneNumber: "+905551111111", Content: "Second", Status: entity.StatusPending, CreatedAt: time.Now().Add(-time.Minute)},
+	}
+	db.Create(&messages)
+
+	tx := db.Begin()
+	defer tx.Rollback()
+	tx.Exec("SELECT id FROM messages WHERE id = 1 FOR UPDATE")
+
+	result, err := repo.ClaimPending(ctx, 10, "worker-2")
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, uint(2), result[0].ID)
+}
+
+func TestRecordFailure(t *testing.T) {
+	db := setupTestDB()
+	repo := NewMessageRepository(db)
+	ctx := context.Background()
+	db.Exec("DELETE FROM messages")
+
+	messages := []entity.Message{
+		{ID: 1, PhoneNumber: "+905551111111", Content: "Test", Status: entity.StatusProcessing, AttemptCount: 1, ClaimedBy: "worker-1", ClaimedAt: time.Now()},
+		{ID: 2, PhoneNumber: "+905551111111", Content: "Test", Status: entity.StatusProcessing, ClaimedBy: "worker-1", ClaimedAt: time.Now()},
+	}
+	db.Create(&messages)
+
+	nextAttemptAt := time.Now().Add(time.Minute)
+	err := repo.RecordFailure(ctx, 1, "worker-1", entity.StatusFailed, "bad request", time.Time{})
+	assert.NoError(t, err)
+
+	err = repo.RecordFailure(ctx, 2, "worker-1", entity.StatusPending, "timeout", nextAttemptAt)
+	assert.NoError(t, err)
+
+	var updated entity.Message
+	db.First(&updated, 1)
+
+	assert.Equal(t, entity.StatusFailed, updated.Status)
+	assert.Equal(t, 2, updated.AttemptCount)
+	assert.Equal(t, "bad request", updated.LastError)
+	assert.True(t, updated.NextAttemptAt.IsZero())
+
+	db.First(&updated, 2)
+
+	assert.Equal(t, entity.StatusPending, updated.Status)
+	assert.Equal(t, 1, updated.AttemptCount)
+	assert.WithinDuration(t, nextAttemptAt, updated.NextAttemptAt, time.Second)
+}
+
+func TestRecordFailureAfterClaimLost(t *testing.T) {
+	db := setupTestDB()
+	repo := NewMessageRepository(db)
+	ctx := context.Background()
+	db.Exec("DELETE FROM messages")
+
+	message := entity.Message{ID: 1, PhoneNumber: "+905551111111", Content: "Test", Status: entity.StatusProcessing, ClaimedBy: "worker-2", ClaimedAt: time.Now()}
+	db.Create(&message)
+
+	err := repo.RecordFailure(ctx, 1, "worker-1", entity.StatusFailed, "bad request", time.Time{})
+	assert.ErrorIs(t, err, ErrClaimLost)
+
+	err = repo.MarkSent(ctx, 1, "worker-1", "primary", model.Response{MessageID: "provider-1"})
+	assert.ErrorIs(t, err, ErrClaimLost)
+
+	err = repo.Release(ctx, 1, "worker-1")
+	assert.ErrorIs(t, err, ErrClaimLost)
+
+	err = repo.RenewClaim(ctx, 1, "worker-1")
+	assert.ErrorIs(t, err, ErrClaimLost)
+
+	var updated entity.Message
+	db.First(&updated, 1)
+
+	assert.Equal(t, entity.StatusProcessing, updated.Status)
+	assert.Equal(t, "worker-2", updated.ClaimedBy)
+	assert.Equal(t, 0, updated.AttemptCount)
+	assert.Empty(t, updated.ProviderMessageID)
+}
+
+func TestReleaseExpiredClaims(t *testing.T) {
+	db := setupTestDB()
+	repo := NewMessageRepository(db)
+	ctx := context.Background()
+	db.Exec("DELETE FROM messages")
+
+	messages := []entity.Message{
+		{ID: 1, PhoneNumber: "+905551111111", Content: "Expired", Status: entity.StatusProcessing, ClaimedBy: "worker-1", ClaimedAt: time.Now().Add(-10 * time.Minute)},
+		{ID: 2, PhoneNumber: "+905551111111", Content: "Expired last attempt", Status: entity.StatusProcessing, AttemptCount: 2, ClaimedBy: "worker-1", ClaimedAt: time.Now().Add(-10 * time.Minute)},
+		{ID: 3, PhoneNumber: "+905551111111", Content: "Active", Status: entity.StatusProcessing, ClaimedBy: "worker-2", ClaimedAt: time.Now()},
+	}
+	db.Create(&messages)
+
+	released, err := repo.ReleaseExpiredClaims(ctx, time.Now().Add(-5*time.Minute), 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), released)
+
+	var message entity.Message
+	db.First(&message, 1)
+	assert.Equal(t, entity.StatusPending, message.Status)
+	assert.Equal(t, 1, message.AttemptCount)
+	assert.Empty(t, message.ClaimedBy)
+
+	db.First(&message, 2)
+	assert.Equal(t, entity.StatusFailed, message.Status)
+
+	db.First(&message, 3)
+	assert.Equal(t, entity.StatusProcessing, message.Status)
+}
+
+func TestMarkSent(t *testing.T) {
+	db := setupTestDB()
+	repo := NewMessageRepository(db)
+	ctx := context.Background()
+	db.Exec("DELETE FROM messages")
+
+	message := entity.Message{ID: 1, PhoneNumber: "+905551111111", Content: "Test", Status: entity.StatusProcessing, ClaimedBy: "worker-1", ClaimedAt: time.Now()}
+	db.Create(&message)
+
+	err := repo.MarkSent(ctx, 1, "worker-1", "primary", model.Response{
+		MessageID: "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849",
+		Raw:       `{"messageId":"67f2f8a8-ea58-4ed0-a6f9-ff217df4d849","message":"Accepted"}`,
+	})
+
+	var updated entity.Message
+	db.First(&updated, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, entity.StatusSent, updated.Status)
+	assert.Equal(t, "primary", updated.ProviderName)
+	assert.Equal(t, "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849", updated.ProviderMessageID)
+	assert.Contains(t, updated.ProviderResponse, "Accepted")
+	assert.WithinDuration(t, time.Now(), updated.SentAt, 2*time.Second)
+
+	found, err := repo.GetByProviderMessageID(ctx, "primary", "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), found.ID)
+
+	_, err = repo.GetByProviderMessageID(ctx, "backup", "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestRecordDelivery(t *testing.T) {
+	db := setupTestDB()
+	repo := NewMessageRepository(db)
+	ctx := context.Background()
+	db.Exec("DELETE FROM messages")
+
+	db.Create(&[]entity.Message{
+		{ID: 1, PhoneNumber: "+905551111111", Content: "Test", Status: entity.StatusSent, ProviderName: "primary", ProviderMessageID: "provider-1"},
+		{ID: 2, PhoneNumber: "+905551111111", Content: "Test", Status: entity.StatusSent, ProviderName: "backup", ProviderMessageID: "provider-1"},
+	})
+
+	deliveredAt := time.Now().Truncate(time.Second)
+	affected, err := repo.RecordDelivery(ctx, "primary", "provider-1", entity.StatusDelivered, deliveredAt, "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	affected, err = repo.RecordDelivery(ctx, "primary", "provider-1", entity.StatusUndelivered, time.Time{}, "expired")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), affected)
+
+	var updated entity.Message
+	db.First(&updated, 1)
+
+	assert.Equal(t, entity.StatusDelivered, updated.Status)
+	assert.WithinDuration(t, deliveredAt, updated.DeliveredAt, time.Second)
+	assert.Empty(t, updated.LastError)
+}
+
+func TestRelease(t *testing.T) {
+	db := setupTestDB()
+	repo := NewMessageRepository(db)
+	ctx := context.Background()
+	db.Exec("DELETE FROM messages")
+
+	message := entity.Message{ID: 1, PhoneNumber: "+905551111111", Content: "Test", Status: entity.StatusProcessing, ClaimedBy: "worker-1", ClaimedAt: time.Now()}
+	db.Create(&message)
+
+	err := repo.Release(ctx, 1, "worker-1")
+
+	var released entity.Message
+	db.First(&released, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, entity.StatusPending, released.Status)
+	assert.Equal(t, 0, released.AttemptCount)
+	assert.Empty(t, released.ClaimedBy)
+}
+
+func TestRenewClaim(t *testing.T) {
+	db := setupTestDB()
+	repo := NewMessageRepository(db)
+	ctx := context.Background()
+	db.Exec("DELETE FROM messages")
+
+	claimedAt := time.Now().Add(-4 * time.Minute)
+	message := entity.Message{ID: 1, PhoneNumber: "+905551111111", Content: "Test", Status: entity.StatusProcessing, ClaimedBy: "worker-1", ClaimedAt: claimedAt}
+	db.Create(&message)
+
+	err := repo.RenewClaim(ctx, 1, "worker-1")
+
+	var renewed entity.Message
+	db.First(&renewed, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, entity.StatusProcessing, renewed.Status)
+	assert.Equal(t, "worker-1", renewed.ClaimedBy)
+	assert.True(t, renewed.ClaimedAt.After(claimedAt))
+}
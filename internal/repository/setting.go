@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"github.com/busragumusel/insider-case/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SettingRepository struct {
+	DB *gorm.DB
+}
+
+type SettingRepo interface {
+	Get(ctx context.Context, key string) (entity.Setting, error)
+	Save(ctx context.Context, setting entity.Setting) error
+}
+
+func NewSettingRepository(DB *gorm.DB) *SettingRepository {
+	return &SettingRepository{DB}
+}
+
+func (r *SettingRepository) Get(ctx context.Context, key string) (entity.Setting, error) {
+	var setting entity.Setting
+	err := r.DB.WithContext(ctx).
+		Where("key = ?", key).
+		First(&setting).Error
+	return setting, err
+}
+
+// Save inserts the setting or overwrites the one stored under the same key.
+func (r *SettingRepository) Save(ctx context.Context, setting entity.Setting) error {
+	return r.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&setting).Error
+}
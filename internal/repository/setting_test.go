@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/busragumusel/insider-case/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestSaveSetting(t *testing.T) {
+	db := setupTestDB()
+	repo := NewSettingRepository(db)
+	ctx := context.Background()
+	db.Exec("DELETE FROM settings")
+
+	_, err := repo.Get(ctx, "processing_state")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	changedAt := time.Now().Truncate(time.Second)
+	err = repo.Save(ctx, entity.Setting{Key: "processing_state", Value: "stopped", ChangedBy: "alice", ChangedAt: changedAt})
+	assert.NoError(t, err)
+
+	err = repo.Save(ctx, entity.Setting{Key: "processing_state", Value: "running", ChangedBy: "bob", ChangedAt: changedAt})
+	assert.NoError(t, err)
+
+	setting, err := repo.Get(ctx, "processing_state")
+	assert.NoError(t, err)
+	assert.Equal(t, "running", setting.Value)
+	assert.Equal(t, "bob", setting.ChangedBy)
+	assert.WithinDuration(t, changedAt, setting.ChangedAt, time.Second)
+}
@@ -0,0 +1,147 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"github.com/busragumusel/insider-case/internal/model"
+	"sync"
+	"time"
+)
+
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive transient failures that
+	// opens the breaker, zero disables it.
+	FailureThreshold int      `json:"failure_threshold"`
+	CoolDown         Duration `json:"cool_down"`
+}
+
+// CircuitBreaker stops calling a provider after repeated failures. Once the
+// cool-down has passed a single trial call is let through (half-open), which
+// closes the breaker again on success or reopens it on failure.
+type CircuitBreaker struct {
+	sender Sender
+	config BreakerConfig
+	now    func() time.Time
+
+	mu            sync.Mutex
+	state         string
+	failures      int
+	openedAt      time.Time
+	trialInFlight bool
+}
+
+func NewCircuitBreaker(sender Sender, config BreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{
+		sender: sender,
+		config: config,
+		now:    time.Now,
+		state:  BreakerClosed,
+	}
+}
+
+func (b *CircuitBreaker) Name() string {
+	return b.sender.Name()
+}
+
+func (b *CircuitBreaker) Send(ctx context.Context, payload model.Payload) (model.Response, error) {
+	if !b.allow() {
+		return model.Response{}, ErrCircuitOpen
+	}
+
+	res, err := b.sender.Send(ctx, payload)
+	b.record(err)
+
+	return res, err
+}
+
+// Available reports whether a call would currently be let through.
+func (b *CircuitBreaker) Available() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		return !b.now().Before(b.openedAt.Add(b.config.CoolDown.Duration()))
+	case BreakerHalfOpen:
+		return !b.trialInFlight
+	default:
+		return true
+	}
+}
+
+func (b *CircuitBreaker) Status() model.ProviderStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := model.ProviderStatus{
+		Type:                b.sender.Name(),
+		CircuitState:        b.state,
+		ConsecutiveFailures: b.failures,
+	}
+	if b.state == BreakerOpen {
+		openUntil := b.openedAt.Add(b.config.CoolDown.Duration())
+		status.OpenUntil = &openUntil
+	}
+
+	return status
+}
+
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Before(b.openedAt.Add(b.config.CoolDown.Duration())) {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.trialInFlight = true
+		return true
+	case BreakerHalfOpen:
+		if b.trialInFlight {
+			return false
+		}
+		b.trialInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *CircuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trialInFlight = false
+
+	// a permanent error means the provider answered and rejected the message,
+	// so it says nothing bad about the provider's health
+	if err == nil || IsPermanent(err) {
+		b.state = BreakerClosed
+		b.failures = 0
+		return
+	}
+
+	// the provider wasn't called, or its answer wasn't waited for
+	if errors.Is(err, context.Canceled) || IsLimiterError(err) {
+		if b.state == BreakerHalfOpen {
+			b.state = BreakerOpen
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.config.FailureThreshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+}
@@ -0,0 +1,90 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/busragumusel/insider-case/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	stub := &stubSender{name: TypeWebhook, err: errors.New("connection refused")}
+	breaker := NewCircuitBreaker(stub, BreakerConfig{FailureThreshold: 2, CoolDown: Duration(time.Minute)})
+
+	ctx := context.Background()
+
+	_, err := breaker.Send(ctx, model.Payload{})
+	assert.EqualError(t, err, "connection refused")
+	assert.Equal(t, BreakerClosed, breaker.Status().CircuitState)
+
+	_, err = breaker.Send(ctx, model.Payload{})
+	assert.EqualError(t, err, "connection refused")
+	assert.Equal(t, BreakerOpen, breaker.Status().CircuitState)
+	assert.False(t, breaker.Available())
+
+	_, err = breaker.Send(ctx, model.Payload{})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 2, stub.calls)
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	stub := &stubSender{name: TypeWebhook, err: errors.New("connection refused")}
+	breaker := NewCircuitBreaker(stub, BreakerConfig{FailureThreshold: 1, CoolDown: Duration(time.Minute)})
+
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+
+	ctx := context.Background()
+
+	_, _ = breaker.Send(ctx, model.Payload{})
+	assert.Equal(t, BreakerOpen, breaker.Status().CircuitState)
+
+	// the trial call after the cool-down fails and reopens the breaker
+	now = now.Add(time.Minute)
+	assert.True(t, breaker.Available())
+	_, err := breaker.Send(ctx, model.Payload{})
+	assert.EqualError(t, err, "connection refused")
+	assert.Equal(t, BreakerOpen, breaker.Status().CircuitState)
+	assert.Equal(t, 2, stub.calls)
+
+	// the next trial succeeds and closes it
+	now = now.Add(time.Minute)
+	stub.err = nil
+	_, err = breaker.Send(ctx, model.Payload{})
+	assert.NoError(t, err)
+	assert.Equal(t, BreakerClosed, breaker.Status().CircuitState)
+	assert.Equal(t, 0, breaker.Status().ConsecutiveFailures)
+}
+
+func TestCircuitBreakerIgnoresPermanentErrors(t *testing.T) {
+	stub := &stubSender{name: TypeWebhook, err: &PermanentError{Err: errors.New("invalid number")}}
+	breaker := NewCircuitBreaker(stub, BreakerConfig{FailureThreshold: 1, CoolDown: Duration(time.Minute)})
+
+	_, err := breaker.Send(context.Background(), model.Payload{})
+
+	assert.True(t, IsPermanent(err))
+	assert.Equal(t, BreakerClosed, breaker.Status().CircuitState)
+}
+
+func TestRouterReportsOpenCircuit(t *testing.T) {
+	breaker := NewCircuitBreaker(&stubSender{name: TypeWebhook, err: errors.New("timeout")}, BreakerConfig{FailureThreshold: 1, CoolDown: Duration(time.Minute)})
+
+	router, err := NewRouter(map[string]Sender{"primary": breaker}, []RouteConfig{{Prefix: "", Providers: []string{"primary"}}})
+	assert.NoError(t, err)
+
+	_, _, err = router.Send(context.Background(), model.Payload{To: "+905551111111"})
+	assert.NotErrorIs(t, err, ErrCircuitOpen)
+
+	_, _, err = router.Send(context.Background(), model.Payload{To: "+905551111111"})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.False(t, router.Available())
+
+	statuses := router.Status()
+	assert.Len(t, statuses, 1)
+	assert.Equal(t, "primary", statuses[0].Name)
+	assert.Equal(t, BreakerOpen, statuses[0].CircuitState)
+	assert.NotNil(t, statuses[0].OpenUntil)
+}
@@ -0,0 +1,182 @@
+package sender
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config names the providers messages can be sent through and routes phone
+// number prefixes to them.
+type Config struct {
+	Providers map[string]ProviderConfig `json:"providers"`
+	Routes    []RouteConfig             `json:"routes"`
+	// CircuitBreaker is applied to every provider.
+	CircuitBreaker BreakerConfig `json:"circuit_breaker"`
+}
+
+type ProviderConfig struct {
+	Type    string         `json:"type"`
+	Webhook *WebhookConfig `json:"webhook,omitempty"`
+	SMTP    *SMTPConfig    `json:"smtp,omitempty"`
+	SMPP    *SMPPConfig    `json:"smpp,omitempty"`
+	// RateLimit is shared by every instance through Redis. Providers
+	// without one aren't limited.
+	RateLimit *RateLimitConfig `json:"rate_limit,omitempty"`
+}
+
+// RouteConfig sends numbers starting with Prefix through Providers, trying
+// them in order. An empty prefix matches every number.
+type RouteConfig struct {
+	Prefix    string   `json:"prefix"`
+	Providers []string `json:"providers"`
+}
+
+// Duration is a time.Duration written as a string such as "30s" in JSON.
+type Duration time.Duration
+
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\": %w", err)
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// LoadConfig reads a JSON config file. ${VAR} references are replaced with
+// environment variables so that secrets don't have to live in the file.
+// Settings missing from the file fall back to the environment.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	config := Config{CircuitBreaker: breakerConfigFromEnv()}
+	if err := json.Unmarshal([]byte(os.ExpandEnv(string(data))), &config); err != nil {
+		return Config{}, fmt.Errorf("invalid provider config: %w", err)
+	}
+
+	return config, nil
+}
+
+// ConfigFromEnv configures the single provider selected by SENDER, which
+// defaults to the webhook, and routes every number to it.
+func ConfigFromEnv() Config {
+	kind := os.Getenv("SENDER")
+	if kind == "" {
+		kind = TypeWebhook
+	}
+
+	provider := ProviderConfig{Type: kind}
+	switch kind {
+	case TypeWebhook:
+		provider.Webhook = &WebhookConfig{
+			URL:             os.Getenv("WEBHOOK_URL"),
+			AuthKey:         os.Getenv("AUTH_KEY"),
+			SigningSecret:   os.Getenv("WEBHOOK_SIGNING_SECRET"),
+			SignatureHeader: os.Getenv("WEBHOOK_SIGNATURE_HEADER"),
+			TimestampHeader: os.Getenv("WEBHOOK_TIMESTAMP_HEADER"),
+
+			AcceptedStatuses:  statusCodesFromEnv("WEBHOOK_ACCEPTED_STATUSES"),
+			MessageIDPath:     os.Getenv("WEBHOOK_MESSAGE_ID_PATH"),
+			PermanentStatuses: statusCodesFromEnv("WEBHOOK_PERMANENT_STATUSES"),
+			RetryableStatuses: statusCodesFromEnv("WEBHOOK_RETRYABLE_STATUSES"),
+		}
+	case TypeSMTP:
+		provider.SMTP = &SMTPConfig{
+			Host:            os.Getenv("SMTP_HOST"),
+			Port:            os.Getenv("SMTP_PORT"),
+			Username:        os.Getenv("SMTP_USERNAME"),
+			Password:        os.Getenv("SMTP_PASSWORD"),
+			From:            os.Getenv("SMTP_FROM"),
+			RecipientDomain: os.Getenv("SMTP_RECIPIENT_DOMAIN"),
+		}
+	case TypeSMPP:
+		provider.SMPP = &SMPPConfig{
+			Addr:       os.Getenv("SMPP_ADDR"),
+			SystemID:   os.Getenv("SMPP_SYSTEM_ID"),
+			Password:   os.Getenv("SMPP_PASSWORD"),
+			SourceAddr: os.Getenv("SMPP_SOURCE_ADDR"),
+		}
+	}
+
+	if perSecond, err := strconv.ParseFloat(os.Getenv("RATE_LIMIT_PER_SECOND"), 64); err == nil && perSecond > 0 {
+		burst, _ := strconv.Atoi(os.Getenv("RATE_LIMIT_BURST"))
+		provider.RateLimit = &RateLimitConfig{PerSecond: perSecond, Burst: burst}
+	}
+
+	return Config{
+		Providers:      map[string]ProviderConfig{kind: provider},
+		Routes:         []RouteConfig{{Prefix: "", Providers: []string{kind}}},
+		CircuitBreaker: breakerConfigFromEnv(),
+	}
+}
+
+// statusCodesFromEnv reads a comma separated list of HTTP status codes,
+// skipping anything that isn't one.
+func statusCodesFromEnv(key string) []int {
+	var codes []int
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		code, err := strconv.Atoi(strings.TrimSpace(value))
+		if err == nil && code >= 100 && code <= 599 {
+			codes = append(codes, code)
+		}
+	}
+
+	return codes
+}
+
+func breakerConfigFromEnv() BreakerConfig {
+	config := BreakerConfig{FailureThreshold: 5, CoolDown: Duration(30 * time.Second)}
+
+	if value, err := strconv.Atoi(os.Getenv("BREAKER_FAILURE_THRESHOLD")); err == nil && value >= 0 {
+		config.FailureThreshold = value
+	}
+	if value, err := time.ParseDuration(os.Getenv("BREAKER_COOL_DOWN")); err == nil && value > 0 {
+		config.CoolDown = Duration(value)
+	}
+
+	return config
+}
+
+func newSender(name string, config ProviderConfig, client *http.Client) (Sender, error) {
+	switch config.Type {
+	case TypeWebhook:
+		if config.Webhook == nil {
+			return nil, fmt.Errorf("provider %q: missing webhook config", name)
+		}
+		return NewWebhookSender(*config.Webhook, client), nil
+	case TypeSMTP:
+		if config.SMTP == nil {
+			return nil, fmt.Errorf("provider %q: missing smtp config", name)
+		}
+		return NewSMTPSender(*config.SMTP), nil
+	case TypeSMPP:
+		if config.SMPP == nil {
+			return nil, fmt.Errorf("provider %q: missing smpp config", name)
+		}
+		return NewSMPPSender(*config.SMPP), nil
+	default:
+		return nil, fmt.Errorf("provider %q: unknown type %q", name, config.Type)
+	}
+}
@@ -0,0 +1,145 @@
+package sender
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultHTTPClient is used by webhook senders that aren't given a client.
+var defaultHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// HTTPClientConfig configures the HTTP client shared by all webhook providers.
+type HTTPClientConfig struct {
+	Timeout             time.Duration
+	DialTimeout         time.Duration
+	KeepAlive           time.Duration
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	// ProxyURL sends requests through an HTTP proxy. When empty, the
+	// standard HTTP_PROXY, HTTPS_PROXY and NO_PROXY variables apply.
+	ProxyURL string
+	// CAFile adds the PEM certificates in the file to the trusted roots.
+	CAFile string
+	// CertFile and KeyFile present a client certificate for mTLS.
+	CertFile string
+	KeyFile  string
+}
+
+func DefaultHTTPClientConfig() HTTPClientConfig {
+	return HTTPClientConfig{
+		Timeout:             10 * time.Second,
+		DialTimeout:         5 * time.Second,
+		KeepAlive:           30 * time.Second,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+// HTTPClientConfigFromEnv reads the HTTP_CLIENT_* variables, keeping the
+// defaults for missing or invalid ones.
+func HTTPClientConfigFromEnv() HTTPClientConfig {
+	config := DefaultHTTPClientConfig()
+
+	durations := []struct {
+		key   string
+		value *time.Duration
+	}{
+		{"HTTP_CLIENT_TIMEOUT", &config.Timeout},
+		{"HTTP_CLIENT_DIAL_TIMEOUT", &config.DialTimeout},
+		{"HTTP_CLIENT_KEEP_ALIVE", &config.KeepAlive},
+		{"HTTP_CLIENT_IDLE_CONN_TIMEOUT", &config.IdleConnTimeout},
+	}
+	for _, d := range durations {
+		if value, err := time.ParseDuration(os.Getenv(d.key)); err == nil && value > 0 {
+			*d.value = value
+		}
+	}
+
+	if value, err := strconv.Atoi(os.Getenv("HTTP_CLIENT_MAX_IDLE_CONNS")); err == nil && value > 0 {
+		config.MaxIdleConns = value
+	}
+	if value, err := strconv.Atoi(os.Getenv("HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST")); err == nil && value > 0 {
+		config.MaxIdleConnsPerHost = value
+	}
+
+	config.ProxyURL = os.Getenv("HTTP_CLIENT_PROXY_URL")
+	config.CAFile = os.Getenv("HTTP_CLIENT_CA_FILE")
+	config.CertFile = os.Getenv("HTTP_CLIENT_CERT_FILE")
+	config.KeyFile = os.Getenv("HTTP_CLIENT_KEY_FILE")
+
+	return config
+}
+
+// NewHTTPClient builds a client with its own connection pool, meant to be
+// shared by every request to the providers.
+func NewHTTPClient(config HTTPClientConfig) (*http.Client, error) {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   config.DialTimeout,
+			KeepAlive: config.KeepAlive,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		IdleConnTimeout:       config.IdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	if config.ProxyURL != "" {
+		proxyURL, err := url.Parse(config.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Timeout: config.Timeout, Transport: transport}, nil
+}
+
+func newTLSConfig(config HTTPClientConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in CA file")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.CertFile != "" || config.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,100 @@
+package sender
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/busragumusel/insider-case/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	config := DefaultHTTPClientConfig()
+	config.ProxyURL = "http://proxy.internal:3128"
+
+	client, err := NewHTTPClient(config)
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Second, client.Timeout)
+
+	transport := client.Transport.(*http.Transport)
+	assert.Equal(t, 100, transport.MaxIdleConns)
+	assert.Equal(t, 10, transport.MaxIdleConnsPerHost)
+
+	proxy, err := transport.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: "example.com"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "proxy.internal:3128", proxy.Host)
+}
+
+func TestNewHTTPClientInvalidTLS(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "ca.pem")
+	assert.NoError(t, os.WriteFile(invalid, []byte("not a certificate"), 0o600))
+
+	tests := []HTTPClientConfig{
+		{CAFile: filepath.Join(dir, "missing.pem")},
+		{CAFile: invalid},
+		{CertFile: filepath.Join(dir, "client.pem"), KeyFile: filepath.Join(dir, "client.key")},
+	}
+
+	for _, config := range tests {
+		_, err := NewHTTPClient(config)
+		assert.Error(t, err)
+	}
+}
+
+func TestNewHTTPClientCustomCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"messageId":"67f2f8a8-ea58-4ed0-a6f9-ff217df4d849","message":"Accepted"}`))
+	}))
+	defer server.Close()
+
+	// the test server's certificate is only trusted through the CA file
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	assert.NoError(t, os.WriteFile(caFile, certificatePEM(server.Certificate().Raw), 0o600))
+
+	config := DefaultHTTPClientConfig()
+	config.CAFile = caFile
+	client, err := NewHTTPClient(config)
+	assert.NoError(t, err)
+
+	res, err := NewWebhookSender(WebhookConfig{URL: server.URL}, client).Send(context.Background(), model.Payload{})
+	assert.NoError(t, err)
+	assert.Equal(t, "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849", res.MessageID)
+
+	_, err = NewWebhookSender(WebhookConfig{URL: server.URL}, nil).Send(context.Background(), model.Payload{})
+	assert.Error(t, err)
+}
+
+func TestWebhookSendCanceled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := NewWebhookSender(WebhookConfig{URL: server.URL}, nil).Send(ctx, model.Payload{})
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func certificatePEM(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
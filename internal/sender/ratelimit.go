@@ -0,0 +1,123 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"github.com/busragumusel/insider-case/internal/model"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// RateLimitConfig limits how many messages a provider is sent across all
+// instances.
+type RateLimitConfig struct {
+	PerSecond float64 `json:"per_second"`
+	// Burst is how many messages may be sent at once after a quiet period,
+	// at least 1.
+	Burst int `json:"burst"`
+}
+
+// takeTokenScript refills the bucket for the time since it was last used and
+// takes a token. It returns 0 when a token was taken, otherwise how many
+// milliseconds until one is available. The time comes from Redis so that the
+// clocks of the instances don't matter.
+var takeTokenScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated_at')
+local tokens = tonumber(state[1])
+local updated_at = tonumber(state[2])
+if tokens == nil or updated_at == nil then
+	tokens = burst
+	updated_at = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - updated_at) * rate / 1000)
+
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+else
+	wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated_at', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+
+return wait
+`)
+
+// Limiter blocks until a message may be sent.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// TokenBucket is a token bucket kept in Redis, so every instance draws from
+// the same one.
+type TokenBucket struct {
+	client *redis.Client
+	key    string
+	config RateLimitConfig
+}
+
+func NewTokenBucket(client *redis.Client, name string, config RateLimitConfig) *TokenBucket {
+	if config.Burst < 1 {
+		config.Burst = 1
+	}
+
+	return &TokenBucket{
+		client: client,
+		key:    "rate_limit:" + name,
+		config: config,
+	}
+}
+
+// Wait takes a token, waiting for one to become available if necessary.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	for {
+		wait, err := takeTokenScript.Run(ctx, b.client, []string{b.key}, b.config.PerSecond, b.config.Burst).Int64()
+		if err != nil {
+			return fmt.Errorf("failed to take rate limit token: %w", err)
+		}
+		if wait <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(time.Duration(wait) * time.Millisecond)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// LimitedSender waits for its limiter before every send.
+type LimitedSender struct {
+	sender  Sender
+	limiter Limiter
+}
+
+func NewLimitedSender(sender Sender, limiter Limiter) *LimitedSender {
+	return &LimitedSender{sender: sender, limiter: limiter}
+}
+
+func (l *LimitedSender) Name() string {
+	return l.sender.Name()
+}
+
+func (l *LimitedSender) Send(ctx context.Context, payload model.Payload) (model.Response, error) {
+	if err := l.limiter.Wait(ctx); err != nil {
+		if ctx.Err() != nil {
+			return model.Response{}, err
+		}
+		return model.Response{}, &LimiterError{Err: err}
+	}
+
+	return l.sender.Send(ctx, payload)
+}
@@ -0,0 +1,98 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/busragumusel/insider-case/internal/model"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubLimiter struct {
+	err   error
+	waits int
+}
+
+func (l *stubLimiter) Wait(_ context.Context) error {
+	l.waits++
+	return l.err
+}
+
+func TestLimitedSender(t *testing.T) {
+	provider := &stubSender{name: "primary"}
+	limiter := &stubLimiter{}
+
+	res, err := NewLimitedSender(provider, limiter).Send(context.Background(), model.Payload{})
+	assert.NoError(t, err)
+	assert.Equal(t, "primary-id", res.MessageID)
+	assert.Equal(t, 1, limiter.waits)
+	assert.Equal(t, 1, provider.calls)
+
+	limiter.err = context.Canceled
+	_, err = NewLimitedSender(provider, limiter).Send(context.Background(), model.Payload{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, provider.calls, "provider must not be called without a token")
+}
+
+func TestLimiterFailureIsNotAProviderFailure(t *testing.T) {
+	provider := &stubSender{name: "primary"}
+	limiter := &stubLimiter{err: errors.New("failed to take rate limit token: connection refused")}
+	breaker := NewCircuitBreaker(NewLimitedSender(provider, limiter), BreakerConfig{FailureThreshold: 1, CoolDown: Duration(time.Minute)})
+
+	router, err := NewRouter(map[string]Sender{"primary": breaker}, []RouteConfig{{Prefix: "", Providers: []string{"primary"}}})
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, _, err = router.Send(context.Background(), model.Payload{})
+		assert.True(t, IsLimiterError(err))
+	}
+	assert.Equal(t, 0, provider.calls)
+	assert.Equal(t, BreakerClosed, breaker.Status().CircuitState)
+}
+
+func TestNewRouterFromConfigRateLimit(t *testing.T) {
+	config := Config{
+		Providers: map[string]ProviderConfig{
+			"primary": {
+				Type:      TypeWebhook,
+				Webhook:   &WebhookConfig{URL: "http://localhost/primary"},
+				RateLimit: &RateLimitConfig{PerSecond: 10},
+			},
+		},
+		Routes: []RouteConfig{{Prefix: "", Providers: []string{"primary"}}},
+	}
+
+	_, err := NewRouterFromConfig(config, nil, nil)
+	assert.Error(t, err, "rate limits need redis")
+
+	config.Providers["primary"].RateLimit.PerSecond = 0
+	_, err = NewRouterFromConfig(config, nil, redis.NewClient(&redis.Options{Addr: "localhost:6379"}))
+	assert.Error(t, err, "rate must be positive")
+}
+
+func TestTokenBucket(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skip("redis is not available:", err)
+	}
+
+	bucket := NewTokenBucket(client, "test-"+time.Now().Format(time.RFC3339Nano), RateLimitConfig{PerSecond: 10, Burst: 2})
+	t.Cleanup(func() { client.Del(ctx, bucket.key) })
+
+	start := time.Now()
+	assert.NoError(t, bucket.Wait(ctx))
+	assert.NoError(t, bucket.Wait(ctx))
+	assert.Less(t, time.Since(start), 50*time.Millisecond, "burst is available right away")
+
+	assert.NoError(t, bucket.Wait(ctx))
+	assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond, "third token takes a refill")
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	err := bucket.Wait(timeout)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+}
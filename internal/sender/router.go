@@ -0,0 +1,177 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/busragumusel/insider-case/internal/model"
+	"github.com/go-redis/redis/v8"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+var ErrNoRoute = errors.New("no provider route")
+
+type route struct {
+	prefix    string
+	providers []string
+}
+
+// Router picks the providers for a message by the longest matching phone
+// number prefix and fails over to the next one on transient errors.
+type Router struct {
+	senders map[string]Sender
+	routes  []route
+}
+
+func NewRouter(senders map[string]Sender, routes []RouteConfig) (*Router, error) {
+	if len(routes) == 0 {
+		return nil, errors.New("at least one route is required")
+	}
+
+	r := &Router{senders: senders}
+	for _, rc := range routes {
+		if len(rc.Providers) == 0 {
+			return nil, fmt.Errorf("route %q has no providers", rc.Prefix)
+		}
+		for _, name := range rc.Providers {
+			if _, ok := senders[name]; !ok {
+				return nil, fmt.Errorf("route %q uses unknown provider %q", rc.Prefix, name)
+			}
+		}
+		r.routes = append(r.routes, route{prefix: rc.Prefix, providers: rc.Providers})
+	}
+
+	sort.SliceStable(r.routes, func(i, j int) bool {
+		return len(r.routes[i].prefix) > len(r.routes[j].prefix)
+	})
+
+	return r, nil
+}
+
+// NewRouterFromConfig creates the configured providers. Webhook providers
+// share client and rate limits are kept in redisClient.
+func NewRouterFromConfig(config Config, client *http.Client, redisClient *redis.Client) (*Router, error) {
+	senders := make(map[string]Sender, len(config.Providers))
+	for name, providerConfig := range config.Providers {
+		sender, err := newSender(name, providerConfig, client)
+		if err != nil {
+			return nil, err
+		}
+		if limit := providerConfig.RateLimit; limit != nil {
+			if limit.PerSecond <= 0 {
+				return nil, fmt.Errorf("provider %q: rate limit must be positive", name)
+			}
+			if redisClient == nil {
+				return nil, fmt.Errorf("provider %q: rate limit requires redis", name)
+			}
+			sender = NewLimitedSender(sender, NewTokenBucket(redisClient, name, *limit))
+		}
+		// the breaker wraps the limiter so that an open breaker doesn't use up tokens
+		if config.CircuitBreaker.FailureThreshold > 0 {
+			sender = NewCircuitBreaker(sender, config.CircuitBreaker)
+		}
+		senders[name] = sender
+	}
+
+	return NewRouter(senders, config.Routes)
+}
+
+// SingleRouter routes every message to sender.
+func SingleRouter(sender Sender) *Router {
+	return &Router{
+		senders: map[string]Sender{sender.Name(): sender},
+		routes:  []route{{prefix: "", providers: []string{sender.Name()}}},
+	}
+}
+
+// Send delivers the payload and returns the name of the provider that
+// accepted it. Permanent errors are returned right away, since another
+// provider would reject the message as well. A number no route matches is a
+// permanent error too, retrying it doesn't change the routes.
+func (r *Router) Send(ctx context.Context, payload model.Payload) (model.Response, string, error) {
+	providers := r.providersFor(payload.To)
+	if providers == nil {
+		return model.Response{}, "", &PermanentError{Err: fmt.Errorf("%w for %s", ErrNoRoute, payload.To)}
+	}
+
+	var lastErr, notAttemptedErr error
+	for _, name := range providers {
+		res, err := r.senders[name].Send(ctx, payload)
+		if err == nil {
+			return res, name, nil
+		}
+
+		if IsPermanent(err) {
+			return model.Response{}, name, err
+		}
+
+		// the other providers would be called with the same canceled context
+		if ctx.Err() != nil {
+			return model.Response{}, name, err
+		}
+
+		switch {
+		case errors.Is(err, ErrCircuitOpen):
+		case IsLimiterError(err):
+			log.Printf("Provider %s could not be rate limited, trying the next one: %v", name, err)
+			notAttemptedErr = fmt.Errorf("%s: %w", name, err)
+		default:
+			log.Printf("Provider %s failed, trying the next one: %v", name, err)
+			lastErr = fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	// a provider that was called and failed decides the outcome
+	if lastErr != nil {
+		return model.Response{}, "", lastErr
+	}
+	if notAttemptedErr != nil {
+		return model.Response{}, "", notAttemptedErr
+	}
+
+	return model.Response{}, "", ErrCircuitOpen
+}
+
+// Available reports whether at least one provider can currently be called.
+func (r *Router) Available() bool {
+	for _, sender := range r.senders {
+		breaker, ok := sender.(*CircuitBreaker)
+		if !ok || breaker.Available() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Status describes every provider, sorted by name.
+func (r *Router) Status() []model.ProviderStatus {
+	statuses := make([]model.ProviderStatus, 0, len(r.senders))
+	for name, sender := range r.senders {
+		status := model.ProviderStatus{Type: sender.Name()}
+		if breaker, ok := sender.(*CircuitBreaker); ok {
+			status = breaker.Status()
+		}
+		status.Name = name
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	return statuses
+}
+
+func (r *Router) providersFor(phoneNumber string) []string {
+	for _, route := range r.routes {
+		if strings.HasPrefix(phoneNumber, route.prefix) {
+			return route.providers
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/busragumusel/insider-case/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubSender struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (s *stubSender) Name() string {
+	return s.name
+}
+
+func (s *stubSender) Send(_ context.Context, _ model.Payload) (model.Response, error) {
+	s.calls++
+	if s.err != nil {
+		return model.Response{}, s.err
+	}
+
+	return model.Response{MessageID: s.name + "-id"}, nil
+}
+
+func TestRouterPicksLongestPrefix(t *testing.T) {
+	local := &stubSender{name: "local"}
+	global := &stubSender{name: "global"}
+
+	router, err := NewRouter(map[string]Sender{"local": local, "global": global}, []RouteConfig{
+		{Prefix: "", Providers: []string{"global"}},
+		{Prefix: "+90", Providers: []string{"local"}},
+	})
+	assert.NoError(t, err)
+
+	res, provider, err := router.Send(context.Background(), model.Payload{To: "+905551111111"})
+	assert.NoError(t, err)
+	assert.Equal(t, "local", provider)
+	assert.Equal(t, "local-id", res.MessageID)
+
+	_, provider, err = router.Send(context.Background(), model.Payload{To: "+15551111111"})
+	assert.NoError(t, err)
+	assert.Equal(t, "global", provider)
+}
+
+func TestRouterFailsOverOnTransientError(t *testing.T) {
+	primary := &stubSender{name: "primary", err: errors.New("connection refused")}
+	backup := &stubSender{name: "backup"}
+
+	router, err := NewRouter(map[string]Sender{"primary": primary, "backup": backup}, []RouteConfig{
+		{Prefix: "+90", Providers: []string{"primary", "backup"}},
+	})
+	assert.NoError(t, err)
+
+	_, provider, err := router.Send(context.Background(), model.Payload{To: "+905551111111"})
+	assert.NoError(t, err)
+	assert.Equal(t, "backup", provider)
+	assert.Equal(t, 1, primary.calls)
+}
+
+func TestRouterStopsOnPermanentError(t *testing.T) {
+	primary := &stubSender{name: "primary", err: &PermanentError{Err: errors.New("invalid number")}}
+	backup := &stubSender{name: "backup"}
+
+	router, err := NewRouter(map[string]Sender{"primary": primary, "backup": backup}, []RouteConfig{
+		{Prefix: "", Providers: []string{"primary", "backup"}},
+	})
+	assert.NoError(t, err)
+
+	_, _, err = router.Send(context.Background(), model.Payload{To: "+905551111111"})
+	assert.True(t, IsPermanent(err))
+	assert.Equal(t, 0, backup.calls)
+}
+
+func TestRouterWithoutMatchingRoute(t *testing.T) {
+	router, err := NewRouter(map[string]Sender{"local": &stubSender{name: "local"}}, []RouteConfig{
+		{Prefix: "+90", Providers: []string{"local"}},
+	})
+	assert.NoError(t, err)
+
+	_, _, err = router.Send(context.Background(), model.Payload{To: "+15551111111"})
+	assert.ErrorIs(t, err, ErrNoRoute)
+	assert.True(t, IsPermanent(err))
+}
+
+func TestNewRouterRejectsUnknownProvider(t *testing.T) {
+	_, err := NewRouter(map[string]Sender{}, []RouteConfig{{Prefix: "", Providers: []string{"missing"}}})
+	assert.Error(t, err)
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("PRIMARY_AUTH_KEY", "secret")
+
+	path := filepath.Join(t.TempDir(), "providers.json")
+	err := os.WriteFile(path, []byte(`{
+		"providers": {
+			"primary": {"type": "webhook", "webhook": {"url": "http://localhost/primary", "auth_key": "${PRIMARY_AUTH_KEY}"}},
+			"backup": {"type": "smpp", "smpp": {"addr": "localhost:2775"}}
+		},
+		"routes": [{"prefix": "+90", "providers": ["primary", "backup"]}]
+	}`), 0o600)
+	assert.NoError(t, err)
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", config.Providers["primary"].Webhook.AuthKey)
+
+	_, err = NewRouterFromConfig(config, nil, nil)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,85 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"github.com/busragumusel/insider-case/internal/model"
+	"time"
+)
+
+const (
+	TypeWebhook = "webhook"
+	TypeSMTP    = "smtp"
+	TypeSMPP    = "smpp"
+)
+
+// Sender delivers a message to a provider.
+type Sender interface {
+	Name() string
+	Send(ctx context.Context, payload model.Payload) (model.Response, error)
+}
+
+// PermanentError marks a send failure that retrying cannot fix, such as the
+// provider rejecting the payload.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+func IsPermanent(err error) bool {
+	var permanentErr *PermanentError
+	return errors.As(err, &permanentErr)
+}
+
+// LimiterError marks a send that wasn't attempted because the rate limiter
+// couldn't be asked for a token, e.g. while Redis is down. It says nothing
+// about the provider.
+type LimiterError struct {
+	Err error
+}
+
+func (e *LimiterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *LimiterError) Unwrap() error {
+	return e.Err
+}
+
+func IsLimiterError(err error) bool {
+	var limiterErr *LimiterError
+	return errors.As(err, &limiterErr)
+}
+
+// RateLimitedError marks a send failure caused by the provider asking us to
+// slow down. RetryAfter is how long it asked us to wait, zero if it didn't say.
+type RateLimitedError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RateLimitedError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter reports whether err is a rate limit and how long the provider
+// asked to wait.
+func RetryAfter(err error) (time.Duration, bool) {
+	var rateLimitedErr *RateLimitedError
+	if !errors.As(err, &rateLimitedErr) {
+		return 0, false
+	}
+
+	return rateLimitedErr.RetryAfter, true
+}
@@ -0,0 +1,26 @@
+package sender
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const (
+	DefaultSignatureHeader = "X-Signature"
+	DefaultTimestampHeader = "X-Timestamp"
+
+	signaturePrefix = "sha256="
+)
+
+// Sign returns the HMAC-SHA256 signature of a request body sent at timestamp,
+// formatted as "sha256=<hex>". The timestamp is part of the signed data so a
+// captured request can't be replayed later with a fresh timestamp.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,271 @@
+package sender
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/busragumusel/insider-case/internal/model"
+	"io"
+	"net"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf16"
+)
+
+const (
+	smppTimeout = 10 * time.Second
+
+	smppBindTransmitter     uint32 = 0x00000002
+	smppBindTransmitterResp uint32 = 0x80000002
+	smppSubmitSM            uint32 = 0x00000004
+	smppSubmitSMResp        uint32 = 0x80000004
+	smppUnbind              uint32 = 0x00000006
+	smppUnbindResp          uint32 = 0x80000006
+	smppEnquireLink         uint32 = 0x00000015
+	smppEnquireLinkResp     uint32 = 0x80000015
+	smppGenericNack         uint32 = 0x80000000
+
+	smppInterfaceVersion = 0x34
+	smppCodingIA5        = 0x01
+	smppCodingUCS2       = 0x08
+	smppMessagePayload   = 0x0424
+	smppMaxShortMessage  = 254
+	smppMaxPDULength     = 64 * 1024
+)
+
+// smppTransientStatuses are command statuses after which the same message
+// may be accepted later, every other non-zero status rejects it for good.
+var smppTransientStatuses = map[uint32]bool{
+	0x00000008: true, // ESME_RSYSERR
+	0x00000014: true, // ESME_RMSGQFUL
+	0x00000045: true, // ESME_RSUBMITFAIL
+	0x00000058: true, // ESME_RTHROTTLED
+}
+
+type SMPPConfig struct {
+	Addr       string `json:"addr"`
+	SystemID   string `json:"system_id"`
+	Password   string `json:"password"`
+	SourceAddr string `json:"source_addr"`
+}
+
+// SMPPSender submits messages to an SMSC over SMPP 3.4. Every send binds as a
+// transmitter, submits a single submit_sm and unbinds again.
+type SMPPSender struct {
+	config SMPPConfig
+}
+
+func NewSMPPSender(config SMPPConfig) *SMPPSender {
+	return &SMPPSender{config: config}
+}
+
+func (s *SMPPSender) Name() string {
+	return TypeSMPP
+}
+
+func (s *SMPPSender) Send(ctx context.Context, payload model.Payload) (model.Response, error) {
+	dialer := net.Dialer{Timeout: smppTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.config.Addr)
+	if err != nil {
+		return model.Response{}, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(smppTimeout)); err != nil {
+		return model.Response{}, err
+	}
+
+	session := &smppSession{conn: conn, reader: bufio.NewReader(conn)}
+
+	// a failed bind is a problem with our account, not with the message, so
+	// it is never permanent
+	if _, err := session.call(smppBindTransmitter, s.bindBody()); err != nil {
+		return model.Response{}, err
+	}
+
+	body, err := session.call(smppSubmitSM, s.submitBody(payload))
+	if err != nil {
+		var statusErr *smppStatusError
+		if errors.As(err, &statusErr) && !smppTransientStatuses[statusErr.status] {
+			return model.Response{}, &PermanentError{Err: err}
+		}
+		return model.Response{}, err
+	}
+
+	messageID, _, _ := bytes.Cut(body, []byte{0})
+
+	// the message is already accepted, so a failing unbind is not an error
+	_, _ = session.call(smppUnbind, nil)
+
+	return model.Response{
+		MessageID: string(messageID),
+		Message:   "Accepted",
+		Raw:       fmt.Sprintf("submit_sm_resp message_id=%s", messageID),
+	}, nil
+}
+
+func (s *SMPPSender) bindBody() []byte {
+	var buf bytes.Buffer
+	writeCString(&buf, s.config.SystemID)
+	writeCString(&buf, s.config.Password)
+	writeCString(&buf, "") // system_type
+	buf.WriteByte(smppInterfaceVersion)
+	buf.WriteByte(0)       // addr_ton
+	buf.WriteByte(0)       // addr_npi
+	writeCString(&buf, "") // address_range
+
+	return buf.Bytes()
+}
+
+func (s *SMPPSender) submitBody(payload model.Payload) []byte {
+	sourceTON, sourceNPI := byte(1), byte(1)
+	if strings.IndexFunc(s.config.SourceAddr, func(r rune) bool { return !unicode.IsDigit(r) }) != -1 {
+		sourceTON, sourceNPI = 5, 0 // alphanumeric sender id
+	}
+
+	dataCoding, message := encodeSMPPMessage(payload.Content)
+
+	var buf bytes.Buffer
+	writeCString(&buf, "") // service_type
+	buf.WriteByte(sourceTON)
+	buf.WriteByte(sourceNPI)
+	writeCString(&buf, s.config.SourceAddr)
+	buf.WriteByte(1) // dest_addr_ton: international
+	buf.WriteByte(1) // dest_addr_npi: ISDN
+	writeCString(&buf, strings.TrimPrefix(payload.To, "+"))
+	buf.WriteByte(0)       // esm_class
+	buf.WriteByte(0)       // protocol_id
+	buf.WriteByte(0)       // priority_flag
+	writeCString(&buf, "") // schedule_delivery_time
+	writeCString(&buf, "") // validity_period
+	buf.WriteByte(1)       // registered_delivery: request a delivery receipt
+	buf.WriteByte(0)       // replace_if_present_flag
+	buf.WriteByte(dataCoding)
+	buf.WriteByte(0) // sm_default_msg_id
+
+	if len(message) <= smppMaxShortMessage {
+		buf.WriteByte(byte(len(message)))
+		buf.Write(message)
+		return buf.Bytes()
+	}
+
+	buf.WriteByte(0) // sm_length, the text goes into the message_payload TLV
+	_ = binary.Write(&buf, binary.BigEndian, uint16(smppMessagePayload))
+	_ = binary.Write(&buf, binary.BigEndian, uint16(len(message)))
+	buf.Write(message)
+
+	return buf.Bytes()
+}
+
+// encodeSMPPMessage sends plain ASCII as IA5 and falls back to UCS2 for
+// anything else. Most SMSCs read the default data coding as the GSM 03.38
+// alphabet, which garbles characters such as @, $, _ and the brackets.
+func encodeSMPPMessage(content string) (byte, []byte) {
+	ascii := true
+	for _, r := range content {
+		if r > unicode.MaxASCII {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return smppCodingIA5, []byte(content)
+	}
+
+	units := utf16.Encode([]rune(content))
+	message := make([]byte, 2*len(units))
+	for i, unit := range units {
+		binary.BigEndian.PutUint16(message[2*i:], unit)
+	}
+
+	return smppCodingUCS2, message
+}
+
+func writeCString(buf *bytes.Buffer, value string) {
+	buf.WriteString(value)
+	buf.WriteByte(0)
+}
+
+type smppStatusError struct {
+	commandID uint32
+	status    uint32
+}
+
+func (e *smppStatusError) Error() string {
+	return fmt.Sprintf("smpp command 0x%08x failed with status 0x%08x", e.commandID, e.status)
+}
+
+type smppSession struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	sequence uint32
+}
+
+// call sends a request and waits for its response, answering keep-alives the
+// SMSC sends in between. It returns the body of the response.
+func (s *smppSession) call(commandID uint32, body []byte) ([]byte, error) {
+	s.sequence++
+	if err := s.write(commandID, 0, s.sequence, body); err != nil {
+		return nil, err
+	}
+
+	for {
+		respID, status, sequence, respBody, err := s.read()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case respID == smppEnquireLink:
+			if err := s.write(smppEnquireLinkResp, 0, sequence, nil); err != nil {
+				return nil, err
+			}
+		case respID == smppGenericNack:
+			return nil, &smppStatusError{commandID: commandID, status: status}
+		case respID == commandID|0x80000000 && sequence == s.sequence:
+			if status != 0 {
+				return nil, &smppStatusError{commandID: commandID, status: status}
+			}
+			return respBody, nil
+		}
+	}
+}
+
+func (s *smppSession) write(commandID, status, sequence uint32, body []byte) error {
+	pdu := make([]byte, 16+len(body))
+	binary.BigEndian.PutUint32(pdu[0:], uint32(len(pdu)))
+	binary.BigEndian.PutUint32(pdu[4:], commandID)
+	binary.BigEndian.PutUint32(pdu[8:], status)
+	binary.BigEndian.PutUint32(pdu[12:], sequence)
+	copy(pdu[16:], body)
+
+	_, err := s.conn.Write(pdu)
+	return err
+}
+
+func (s *smppSession) read() (commandID, status, sequence uint32, body []byte, err error) {
+	header := make([]byte, 16)
+	if _, err = io.ReadFull(s.reader, header); err != nil {
+		return 0, 0, 0, nil, err
+	}
+
+	length := binary.BigEndian.Uint32(header[0:])
+	if length < 16 || length > smppMaxPDULength {
+		return 0, 0, 0, nil, fmt.Errorf("invalid smpp pdu length %d", length)
+	}
+
+	body = make([]byte, length-16)
+	if _, err = io.ReadFull(s.reader, body); err != nil {
+		return 0, 0, 0, nil, err
+	}
+
+	return binary.BigEndian.Uint32(header[4:]),
+		binary.BigEndian.Uint32(header[8:]),
+		binary.BigEndian.Uint32(header[12:]),
+		body,
+		nil
+}
@@ -0,0 +1,111 @@
+package sender
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"testing"
+
+	"github.com/busragumusel/insider-case/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// startSMSC accepts a single SMPP session, answering submit_sm with
+// submitStatus, and reports the submit_sm body it received.
+func startSMSC(t *testing.T, submitStatus uint32) (string, <-chan []byte) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	submitted := make(chan []byte, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		session := &smppSession{conn: conn, reader: bufio.NewReader(conn)}
+		for {
+			commandID, _, sequence, body, err := session.read()
+			if err != nil {
+				return
+			}
+
+			switch commandID {
+			case smppBindTransmitter:
+				// exercise the keep-alive handling before answering the bind
+				session.write(smppEnquireLink, 0, 99, nil)
+				session.read()
+				session.write(smppBindTransmitterResp, 0, sequence, []byte("smsc\x00"))
+			case smppSubmitSM:
+				submitted <- body
+				session.write(smppSubmitSMResp, submitStatus, sequence, []byte("msg-1\x00"))
+			case smppUnbind:
+				session.write(smppUnbindResp, 0, sequence, nil)
+				return
+			}
+		}
+	}()
+
+	return listener.Addr().String(), submitted
+}
+
+func TestSMPPSend(t *testing.T) {
+	addr, submitted := startSMSC(t, 0)
+
+	sender := NewSMPPSender(SMPPConfig{Addr: addr, SystemID: "user", Password: "pass", SourceAddr: "INSIDER"})
+
+	res, err := sender.Send(context.Background(), model.Payload{To: "+905551111111", Content: "Hello"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "msg-1", res.MessageID)
+
+	body := <-submitted
+	assert.True(t, bytes.Contains(body, []byte("\x05\x00INSIDER\x00\x01\x01905551111111\x00")))
+	assert.True(t, bytes.HasSuffix(body, []byte("\x01\x00\x05Hello")))
+}
+
+func TestEncodeSMPPMessage(t *testing.T) {
+	coding, message := encodeSMPPMessage("Pay $5 @ {shop}_[1]~^|\\`")
+	assert.Equal(t, byte(smppCodingIA5), coding)
+	assert.Equal(t, []byte("Pay $5 @ {shop}_[1]~^|\\`"), message)
+
+	coding, message = encodeSMPPMessage("€")
+	assert.Equal(t, byte(smppCodingUCS2), coding)
+	assert.Equal(t, []byte{0x20, 0xac}, message)
+}
+
+func TestSMPPSendUCS2(t *testing.T) {
+	addr, submitted := startSMSC(t, 0)
+
+	sender := NewSMPPSender(SMPPConfig{Addr: addr, SourceAddr: "12345"})
+
+	_, err := sender.Send(context.Background(), model.Payload{To: "+905551111111", Content: "Çay"})
+
+	assert.NoError(t, err)
+
+	body := <-submitted
+	assert.True(t, bytes.HasSuffix(body, []byte("\x08\x00\x06\x00\xc7\x00a\x00y")))
+}
+
+func TestSMPPSendErrors(t *testing.T) {
+	tests := []struct {
+		status    uint32
+		permanent bool
+	}{
+		{0x0000000B, true},  // ESME_RINVDSTADR
+		{0x00000058, false}, // ESME_RTHROTTLED
+	}
+
+	for _, tt := range tests {
+		addr, _ := startSMSC(t, tt.status)
+
+		_, err := NewSMPPSender(SMPPConfig{Addr: addr}).Send(context.Background(), model.Payload{To: "+905551111111", Content: "Hello"})
+
+		assert.Error(t, err)
+		assert.Equal(t, tt.permanent, IsPermanent(err), tt.status)
+	}
+}
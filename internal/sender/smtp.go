@@ -0,0 +1,146 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/busragumusel/insider-case/internal/model"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+const smtpTimeout = 10 * time.Second
+
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     string `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+	// RecipientDomain is the email-to-SMS gateway domain, messages to
+	// +905551111111 are mailed to 905551111111@RecipientDomain.
+	RecipientDomain string `json:"recipient_domain"`
+}
+
+// SMTPSender delivers messages through an email-to-SMS gateway.
+type SMTPSender struct {
+	config SMTPConfig
+}
+
+func NewSMTPSender(config SMTPConfig) *SMTPSender {
+	return &SMTPSender{config: config}
+}
+
+func (s *SMTPSender) Name() string {
+	return TypeSMTP
+}
+
+func (s *SMTPSender) Send(ctx context.Context, payload model.Payload) (model.Response, error) {
+	dialer := net.Dialer{Timeout: smtpTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.config.Host, s.config.Port))
+	if err != nil {
+		return model.Response{}, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(smtpTimeout)); err != nil {
+		return model.Response{}, err
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		return model.Response{}, err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.config.Host}); err != nil {
+			return model.Response{}, err
+		}
+	}
+
+	if s.config.Username != "" {
+		auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return model.Response{}, classifySMTPError(err)
+		}
+	}
+
+	messageID, err := newMessageID(s.config.Host)
+	if err != nil {
+		return model.Response{}, err
+	}
+
+	recipient := strings.TrimPrefix(payload.To, "+") + "@" + s.config.RecipientDomain
+
+	if err := client.Mail(s.config.From); err != nil {
+		return model.Response{}, classifySMTPError(err)
+	}
+	if err := client.Rcpt(recipient); err != nil {
+		return model.Response{}, classifySMTPError(err)
+	}
+
+	wc, err := client.Data()
+	if err != nil {
+		return model.Response{}, classifySMTPError(err)
+	}
+	if _, err := wc.Write(s.buildMessage(messageID, recipient, payload.Content)); err != nil {
+		return model.Response{}, err
+	}
+	if err := wc.Close(); err != nil {
+		return model.Response{}, classifySMTPError(err)
+	}
+
+	if err := client.Quit(); err != nil {
+		return model.Response{}, classifySMTPError(err)
+	}
+
+	return model.Response{
+		MessageID: messageID,
+		Message:   "Accepted",
+		Raw:       fmt.Sprintf("accepted for %s", recipient),
+	}, nil
+}
+
+func (s *SMTPSender) buildMessage(messageID, recipient, content string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", s.config.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", recipient)
+	fmt.Fprintf(&buf, "Message-ID: <%s>\r\n", messageID)
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(content)
+	buf.WriteString("\r\n")
+
+	return buf.Bytes()
+}
+
+func newMessageID(host string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b) + "@" + host, nil
+}
+
+// classifySMTPError treats 5xx replies as permanent, 4xx replies are the
+// server asking us to try again later.
+func classifySMTPError(err error) error {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+		return &PermanentError{Err: err}
+	}
+
+	return err
+}
@@ -0,0 +1,107 @@
+package sender
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/busragumusel/insider-case/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// startSMTPServer runs a minimal SMTP server that answers RCPT with rcptReply
+// and reports the recipient and message data it received.
+func startSMTPServer(t *testing.T, rcptReply string) (string, <-chan []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan []string, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		var rcpt string
+		var data []string
+
+		reply("220 localhost ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(strings.TrimSpace(line))
+
+			switch {
+			case strings.HasPrefix(command, "EHLO"):
+				reply("250 localhost")
+			case strings.HasPrefix(command, "MAIL"):
+				reply("250 OK")
+			case strings.HasPrefix(command, "RCPT"):
+				rcpt = strings.TrimSpace(line)
+				reply(rcptReply)
+			case command == "DATA":
+				reply("354 Go ahead")
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil || dataLine == ".\r\n" {
+						break
+					}
+					data = append(data, strings.TrimRight(dataLine, "\r\n"))
+				}
+				reply("250 Queued")
+			case command == "QUIT":
+				reply("221 Bye")
+				received <- append([]string{rcpt}, data...)
+				return
+			default:
+				reply("502 Not implemented")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), received
+}
+
+func TestSMTPSend(t *testing.T) {
+	addr, received := startSMTPServer(t, "250 OK")
+	host, port, _ := net.SplitHostPort(addr)
+
+	sender := NewSMTPSender(SMTPConfig{
+		Host:            host,
+		Port:            port,
+		From:            "sms@example.com",
+		RecipientDomain: "sms.example.com",
+	})
+
+	res, err := sender.Send(context.Background(), model.Payload{To: "+905551111111", Content: "Hello"})
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, res.MessageID)
+
+	lines := <-received
+	assert.Equal(t, "RCPT TO:<905551111111@sms.example.com>", lines[0])
+	assert.Contains(t, lines, "Message-ID: <"+res.MessageID+">")
+	assert.Equal(t, "Hello", lines[len(lines)-1])
+}
+
+func TestSMTPSendRejectedRecipientIsPermanent(t *testing.T) {
+	addr, _ := startSMTPServer(t, "550 No such user")
+	host, port, _ := net.SplitHostPort(addr)
+
+	sender := NewSMTPSender(SMTPConfig{Host: host, Port: port, From: "sms@example.com", RecipientDomain: "sms.example.com"})
+
+	_, err := sender.Send(context.Background(), model.Payload{To: "+905551111111", Content: "Hello"})
+
+	assert.Error(t, err)
+	assert.True(t, IsPermanent(err))
+}
@@ -0,0 +1,215 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/busragumusel/insider-case/internal/model"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type WebhookConfig struct {
+	URL     string `json:"url"`
+	AuthKey string `json:"auth_key"`
+
+	// SigningSecret enables HMAC signing of the request body when set.
+	SigningSecret   string `json:"signing_secret,omitempty"`
+	SignatureHeader string `json:"signature_header,omitempty"`
+	TimestampHeader string `json:"timestamp_header,omitempty"`
+
+	// AcceptedStatuses are the status codes that mean the message was
+	// accepted, 202 by default.
+	AcceptedStatuses []int `json:"accepted_statuses,omitempty"`
+	// MessageIDPath is the dot separated path of the message id in the
+	// response body, such as "data.id" or "messages.0.id". Defaults to
+	// "messageId".
+	MessageIDPath string `json:"message_id_path,omitempty"`
+	// PermanentStatuses and RetryableStatuses override which failed status
+	// codes end a message and which are retried. By default 4xx other than
+	// 408 and 429 are permanent and everything else is retried.
+	PermanentStatuses []int `json:"permanent_statuses,omitempty"`
+	RetryableStatuses []int `json:"retryable_statuses,omitempty"`
+}
+
+// WebhookSender posts the payload as JSON and reads the provider's message id
+// from the response. With a signing secret, the body is signed together with
+// the time it was sent.
+type WebhookSender struct {
+	config WebhookConfig
+	client *http.Client
+}
+
+// NewWebhookSender sends requests with client, or with a default client
+// when it is nil.
+func NewWebhookSender(config WebhookConfig, client *http.Client) *WebhookSender {
+	if client == nil {
+		client = defaultHTTPClient
+	}
+
+	if config.SignatureHeader == "" {
+		config.SignatureHeader = DefaultSignatureHeader
+	}
+	if config.TimestampHeader == "" {
+		config.TimestampHeader = DefaultTimestampHeader
+	}
+	if len(config.AcceptedStatuses) == 0 {
+		config.AcceptedStatuses = []int{http.StatusAccepted}
+	}
+	if config.MessageIDPath == "" {
+		config.MessageIDPath = "messageId"
+	}
+
+	return &WebhookSender{config: config, client: client}
+}
+
+func (w *WebhookSender) Name() string {
+	return TypeWebhook
+}
+
+func (w *WebhookSender) Send(ctx context.Context, payload model.Payload) (model.Response, error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return model.Response{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.URL, bytes.NewReader(jsonData))
+	if err != nil {
+		return model.Response{}, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-ins-auth-key", w.config.AuthKey)
+
+	if w.config.SigningSecret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(w.config.TimestampHeader, timestamp)
+		req.Header.Set(w.config.SignatureHeader, Sign(w.config.SigningSecret, timestamp, jsonData))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return model.Response{}, err
+	}
+	defer resp.Body.Close()
+
+	if !slices.Contains(w.config.AcceptedStatuses, resp.StatusCode) {
+		err := errors.New("failed to send request: " + resp.Status)
+		if w.isPermanentStatus(resp.StatusCode) {
+			return model.Response{}, &PermanentError{Err: err}
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return model.Response{}, &RateLimitedError{
+				Err:        err,
+				RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+			}
+		}
+		return model.Response{}, err
+	}
+
+	// the provider accepted the message, so from here on nothing may turn
+	// the send into a failure, which would have the message sent again
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("failed to read response of %s: %v", w.config.URL, err)
+	}
+
+	response := model.Response{Raw: string(body)}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		log.Printf("response of %s is not JSON, no message id at %q", w.config.URL, w.config.MessageIDPath)
+		return response, nil
+	}
+
+	if id, ok := lookupPath(decoded, w.config.MessageIDPath); ok {
+		response.MessageID = fmt.Sprint(id)
+	} else {
+		log.Printf("response of %s has no message id at %q", w.config.URL, w.config.MessageIDPath)
+	}
+	if fields, ok := decoded.(map[string]interface{}); ok {
+		response.Message, _ = fields["message"].(string)
+	}
+
+	return response, nil
+}
+
+func (w *WebhookSender) isPermanentStatus(code int) bool {
+	if slices.Contains(w.config.PermanentStatuses, code) {
+		return true
+	}
+	if slices.Contains(w.config.RetryableStatuses, code) {
+		return false
+	}
+
+	return isPermanentStatus(code)
+}
+
+// isPermanentStatus reports whether the provider rejected the request itself.
+// Timeouts and rate limiting are client errors too, but may succeed later.
+func isPermanentStatus(code int) bool {
+	if code == http.StatusRequestTimeout || code == http.StatusTooManyRequests {
+		return false
+	}
+
+	return code >= 400 && code < 500
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an
+// HTTP date. It returns zero when the header is missing or invalid.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
+
+// lookupPath walks a decoded JSON value along a dot separated path of object
+// keys and array indexes. Only strings and numbers count as found.
+func lookupPath(value interface{}, path string) (interface{}, bool) {
+	for _, key := range strings.Split(path, ".") {
+		switch node := value.(type) {
+		case map[string]interface{}:
+			value = node[key]
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			value = node[index]
+		default:
+			return nil, false
+		}
+	}
+
+	switch value.(type) {
+	case string, json.Number:
+		return value, true
+	default:
+		return nil, false
+	}
+}
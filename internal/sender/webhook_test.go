@@ -0,0 +1,231 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/busragumusel/insider-case/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookSend(t *testing.T) {
+	var received model.Payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("x-ins-auth-key"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"messageId":"67f2f8a8-ea58-4ed0-a6f9-ff217df4d849","message":"Accepted"}`))
+	}))
+	defer server.Close()
+
+	sender := NewWebhookSender(WebhookConfig{URL: server.URL, AuthKey: "secret"}, nil)
+
+	res, err := sender.Send(context.Background(), model.Payload{To: "+905551111111", Content: "Hello"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849", res.MessageID)
+	assert.Equal(t, model.Payload{To: "+905551111111", Content: "Hello"}, received)
+}
+
+func TestWebhookSendSigned(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		timestamp := r.Header.Get("X-Request-Time")
+		sent, err := strconv.ParseInt(timestamp, 10, 64)
+		assert.NoError(t, err)
+		assert.InDelta(t, time.Now().Unix(), sent, 5)
+		assert.Equal(t, Sign("signing-secret", timestamp, body), r.Header.Get("X-Request-Signature"))
+		assert.Empty(t, r.Header.Get(DefaultSignatureHeader))
+
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"messageId":"67f2f8a8-ea58-4ed0-a6f9-ff217df4d849","message":"Accepted"}`))
+	}))
+	defer server.Close()
+
+	sender := NewWebhookSender(WebhookConfig{
+		URL:             server.URL,
+		SigningSecret:   "signing-secret",
+		SignatureHeader: "X-Request-Signature",
+		TimestampHeader: "X-Request-Time",
+	}, nil)
+
+	_, err := sender.Send(context.Background(), model.Payload{To: "+905551111111", Content: "Hello"})
+	assert.NoError(t, err)
+}
+
+func TestWebhookSendUnsigned(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get(DefaultSignatureHeader))
+		assert.Empty(t, r.Header.Get(DefaultTimestampHeader))
+
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"messageId":"67f2f8a8-ea58-4ed0-a6f9-ff217df4d849","message":"Accepted"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewWebhookSender(WebhookConfig{URL: server.URL}, nil).Send(context.Background(), model.Payload{})
+	assert.NoError(t, err)
+}
+
+func TestSign(t *testing.T) {
+	signature := Sign("secret", "1735725600", []byte(`{"to":"+905551111111"}`))
+
+	assert.Equal(t, "sha256=", signature[:7])
+	assert.Len(t, signature, 7+64)
+	assert.Equal(t, signature, Sign("secret", "1735725600", []byte(`{"to":"+905551111111"}`)))
+	assert.NotEqual(t, signature, Sign("secret", "1735725601", []byte(`{"to":"+905551111111"}`)))
+	assert.NotEqual(t, signature, Sign("other", "1735725600", []byte(`{"to":"+905551111111"}`)))
+}
+
+func TestWebhookSendErrors(t *testing.T) {
+	tests := []struct {
+		status    int
+		permanent bool
+	}{
+		{http.StatusBadRequest, true},
+		{http.StatusTooManyRequests, false},
+		{http.StatusInternalServerError, false},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(tt.status)
+		}))
+
+		_, err := NewWebhookSender(WebhookConfig{URL: server.URL}, nil).Send(context.Background(), model.Payload{})
+
+		assert.Error(t, err)
+		assert.Equal(t, tt.permanent, IsPermanent(err), tt.status)
+
+		server.Close()
+	}
+}
+
+func TestWebhookSendResponseMapping(t *testing.T) {
+	tests := []struct {
+		path string
+		body string
+		id   string
+	}{
+		{"", `{"messageId":"67f2f8a8-ea58-4ed0-a6f9-ff217df4d849","message":"Accepted"}`, "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849"},
+		{"data.id", `{"data":{"id":"abc"}}`, "abc"},
+		{"messages.0.id", `{"messages":[{"id":12345678901234}]}`, "12345678901234"},
+		{"data.id", `{"data":{}}`, ""},
+		{"messages.1.id", `{"messages":[{"id":"abc"}]}`, ""},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(tt.body))
+		}))
+
+		sender := NewWebhookSender(WebhookConfig{
+			URL:              server.URL,
+			AcceptedStatuses: []int{http.StatusOK, http.StatusCreated},
+			MessageIDPath:    tt.path,
+		}, nil)
+
+		res, err := sender.Send(context.Background(), model.Payload{})
+
+		assert.NoError(t, err, tt.body)
+		assert.Equal(t, tt.id, res.MessageID, tt.body)
+		assert.Equal(t, tt.body, res.Raw)
+
+		server.Close()
+	}
+}
+
+func TestWebhookSendAcceptedWithoutJSON(t *testing.T) {
+	for _, body := range []string{"", "OK"} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(body))
+		}))
+
+		sender := NewWebhookSender(WebhookConfig{
+			URL:              server.URL,
+			AcceptedStatuses: []int{http.StatusOK, http.StatusAccepted},
+		}, nil)
+
+		res, err := sender.Send(context.Background(), model.Payload{})
+
+		assert.NoError(t, err, body)
+		assert.Empty(t, res.MessageID, body)
+		assert.Equal(t, body, res.Raw)
+
+		server.Close()
+	}
+}
+
+func TestWebhookSendStatusClassification(t *testing.T) {
+	tests := []struct {
+		status    int
+		permanent bool
+	}{
+		{http.StatusAccepted, false},
+		{http.StatusConflict, false},
+		{http.StatusBadRequest, true},
+		{http.StatusNotImplemented, true},
+		{http.StatusServiceUnavailable, false},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(tt.status)
+		}))
+
+		sender := NewWebhookSender(WebhookConfig{
+			URL:               server.URL,
+			AcceptedStatuses:  []int{http.StatusOK},
+			PermanentStatuses: []int{http.StatusNotImplemented},
+			RetryableStatuses: []int{http.StatusConflict},
+		}, nil)
+
+		_, err := sender.Send(context.Background(), model.Payload{})
+
+		assert.Error(t, err, tt.status)
+		assert.Equal(t, tt.permanent, IsPermanent(err), tt.status)
+
+		server.Close()
+	}
+}
+
+func TestWebhookSendRateLimited(t *testing.T) {
+	tests := []struct {
+		retryAfter string
+		expected   time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"soon", 0},
+		{time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), time.Hour},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if tt.retryAfter != "" {
+				w.Header().Set("Retry-After", tt.retryAfter)
+			}
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+
+		_, err := NewWebhookSender(WebhookConfig{URL: server.URL}, nil).Send(context.Background(), model.Payload{})
+
+		retryAfter, ok := RetryAfter(err)
+		assert.True(t, ok, tt.retryAfter)
+		assert.False(t, IsPermanent(err), tt.retryAfter)
+		assert.InDelta(t, tt.expected, retryAfter, float64(2*time.Second), tt.retryAfter)
+
+		server.Close()
+	}
+}
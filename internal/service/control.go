@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/busragumusel/insider-case/internal/entity"
+	"github.com/busragumusel/insider-case/internal/model"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+const (
+	StateRunning = "running"
+	StateStopped = "stopped"
+
+	processingStateKey = "processing_state"
+	controlChannel     = "processing:control"
+
+	processingRateKey = "processing_rate"
+	rateChannel       = "processing:rate"
+
+	// stateSyncInterval bounds how long an instance can miss a broadcast,
+	// for example while its connection to Redis was down.
+	stateSyncInterval = 30 * time.Second
+)
+
+// RequestStart asks every instance to start processing. The state is stored
+// so that instances started later, or restarted, start processing as well.
+func (s *MessageService) RequestStart(ctx context.Context, changedBy string) (model.ProcessingState, error) {
+	return s.broadcastState(ctx, StateRunning, changedBy)
+}
+
+// RequestStop asks every instance to stop processing. The state is stored so
+// that instances started later, or restarted, stay stopped as well.
+func (s *MessageService) RequestStop(ctx context.Context, changedBy string) (model.ProcessingState, error) {
+	return s.broadcastState(ctx, StateStopped, changedBy)
+}
+
+func (s *MessageService) broadcastState(ctx context.Context, state string, changedBy string) (model.ProcessingState, error) {
+	changedAt := time.Now()
+	desired := model.ProcessingState{
+		State:     state,
+		ChangedBy: changedBy,
+		ChangedAt: &changedAt,
+	}
+
+	if s.settings != nil {
+		err := s.settings.Save(ctx, entity.Setting{
+			Key:       processingStateKey,
+			Value:     state,
+			ChangedBy: changedBy,
+			ChangedAt: changedAt,
+		})
+		if err != nil {
+			return model.ProcessingState{}, err
+		}
+	}
+
+	payload, err := json.Marshal(desired)
+	if err != nil {
+		return model.ProcessingState{}, err
+	}
+
+	// a stored state still reaches every instance with its next sync
+	if err := s.redisClient.Publish(ctx, controlChannel, payload).Err(); err != nil {
+		if s.settings == nil {
+			return model.ProcessingState{}, err
+		}
+		log.Println("Error broadcasting processing state:", err)
+	}
+
+	return desired, nil
+}
+
+func (s *MessageService) broadcastRate(ctx context.Context, interval time.Duration, batchSize int) error {
+	payload, err := json.Marshal(model.RateConfig{
+		Interval:  interval.String(),
+		BatchSize: batchSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.settings != nil {
+		err := s.settings.Save(ctx, entity.Setting{
+			Key:       processingRateKey,
+			Value:     string(payload),
+			ChangedAt: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// the stored rate still reaches the other instances with their next sync
+	if err := s.redisClient.Publish(ctx, rateChannel, payload).Err(); err != nil {
+		log.Println("Error broadcasting processing rate:", err)
+	}
+
+	return nil
+}
+
+// FollowDesiredState starts or stops processing on this instance according
+// to the stored state and rate, and then follows the start and stop requests
+// and rate changes broadcast to all instances until ctx is done. Processing
+// runs when no state has been stored yet.
+func (s *MessageService) FollowDesiredState(ctx context.Context) {
+	pubsub := s.redisClient.Subscribe(ctx, controlChannel, rateChannel)
+	defer pubsub.Close()
+
+	// subscribe before reading the stored state, so that a request made in
+	// between isn't missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		log.Println("Error subscribing to processing control:", err)
+	}
+	s.syncDesiredState(ctx)
+
+	ticker := time.NewTicker(stateSyncInterval)
+	defer ticker.Stop()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			if msg.Channel == rateChannel {
+				s.applyRateConfig(msg.Payload)
+				break
+			}
+
+			var desired model.ProcessingState
+			if err := json.Unmarshal([]byte(msg.Payload), &desired); err != nil {
+				log.Println("Error decoding processing control message:", err)
+				break
+			}
+			s.applyState(ctx, desired)
+		case <-ticker.C:
+			s.syncDesiredState(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *MessageService) syncDesiredState(ctx context.Context) {
+	s.syncRate(ctx)
+
+	desired, err := s.loadDesiredState(ctx)
+	if err != nil {
+		log.Println("Error reading desired processing state:", err)
+		return
+	}
+
+	s.applyState(ctx, desired)
+}
+
+// loadDesiredState reads the stored state. Without a store the state last
+// broadcast is all there is to go by.
+func (s *MessageService) loadDesiredState(ctx context.Context) (model.ProcessingState, error) {
+	if s.settings == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		return s.desired, nil
+	}
+
+	setting, err := s.settings.Get(ctx, processingStateKey)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.ProcessingState{State: StateRunning}, nil
+	}
+	if err != nil {
+		return model.ProcessingState{}, err
+	}
+
+	return model.ProcessingState{
+		State:     setting.Value,
+		ChangedBy: setting.ChangedBy,
+		ChangedAt: timePtr(setting.ChangedAt),
+	}, nil
+}
+
+// syncRate applies the stored rate. Without one, the configured rate is kept.
+func (s *MessageService) syncRate(ctx context.Context) {
+	if s.settings == nil {
+		return
+	}
+
+	setting, err := s.settings.Get(ctx, processingRateKey)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return
+	}
+	if err != nil {
+		log.Println("Error reading processing rate:", err)
+		return
+	}
+
+	s.applyRateConfig(setting.Value)
+}
+
+func (s *MessageService) applyRateConfig(value string) {
+	var rate model.RateConfig
+	if err := json.Unmarshal([]byte(value), &rate); err != nil {
+		log.Println("Error decoding processing rate:", err)
+		return
+	}
+
+	interval, err := time.ParseDuration(rate.Interval)
+	if err == nil {
+		err = validateRate(interval, rate.BatchSize)
+	}
+	if err != nil {
+		log.Printf("Ignoring invalid processing rate %s: %v", value, err)
+		return
+	}
+
+	s.applyRate(interval, rate.BatchSize)
+}
+
+func (s *MessageService) applyState(ctx context.Context, desired model.ProcessingState) {
+	if desired.State != StateRunning && desired.State != StateStopped {
+		log.Printf("Ignoring unknown processing state %q.", desired.State)
+		return
+	}
+
+	s.mu.Lock()
+	s.desired = desired
+	running := s.running
+	done := s.runDone
+	s.mu.Unlock()
+
+	if desired.State == StateRunning && !running {
+		s.StartProcess(ctx)
+	}
+	if desired.State == StateStopped && running {
+		s.StopProcess()
+
+		// the process counts as running until its loop has ended, so a start
+		// that follows right away would otherwise be taken as already done
+		if done != nil {
+			<-done
+		}
+	}
+}
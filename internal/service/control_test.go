@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/busragumusel/insider-case/internal/entity"
+	"github.com/busragumusel/insider-case/internal/model"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type stubSettingRepo struct {
+	mu       sync.Mutex
+	settings map[string]entity.Setting
+}
+
+func (r *stubSettingRepo) Get(_ context.Context, key string) (entity.Setting, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	setting, ok := r.settings[key]
+	if !ok {
+		return entity.Setting{}, gorm.ErrRecordNotFound
+	}
+	return setting, nil
+}
+
+func (r *stubSettingRepo) Save(_ context.Context, setting entity.Setting) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.settings == nil {
+		r.settings = make(map[string]entity.Setting)
+	}
+	r.settings[setting.Key] = setting
+	return nil
+}
+
+func TestApplyState(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+	running := model.ProcessingState{State: StateRunning}
+	stopped := model.ProcessingState{State: StateStopped, ChangedBy: "alice"}
+
+	service.applyState(ctx, stopped)
+	assert.False(t, service.Status().Running)
+	assert.Equal(t, stopped, service.Status().DesiredState)
+
+	service.applyState(ctx, running)
+	assert.True(t, service.Status().Running)
+
+	service.applyState(ctx, running)
+	assert.True(t, service.Status().Running)
+
+	service.applyState(ctx, model.ProcessingState{State: "paused"})
+	assert.Equal(t, running, service.Status().DesiredState)
+
+	service.applyState(ctx, stopped)
+	assert.Eventually(t, func() bool { return !service.Status().Running }, time.Second, 10*time.Millisecond)
+
+	// stopping twice must not leave a stop signal behind for the next run
+	service.applyState(ctx, stopped)
+	service.StopProcess()
+	service.applyState(ctx, running)
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, service.Status().Running)
+
+	service.StopProcess()
+}
+
+func TestApplyStateStartsRightAfterStop(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	service := NewMessageService(new(MockMessageRepo), make(chan bool, 1), setupRedisClient(), &mu, false)
+	service.applyState(ctx, model.ProcessingState{State: StateRunning})
+	assert.True(t, service.Status().Running)
+
+	service.applyState(ctx, model.ProcessingState{State: StateStopped})
+	service.applyState(ctx, model.ProcessingState{State: StateRunning})
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, service.Status().Running)
+
+	service.StopProcess()
+}
+
+func TestStartupRespectsStoredState(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changedAt := time.Now().Truncate(time.Second)
+	settings := &stubSettingRepo{}
+	assert.NoError(t, settings.Save(ctx, entity.Setting{
+		Key:       processingStateKey,
+		Value:     StateStopped,
+		ChangedBy: "alice",
+		ChangedAt: changedAt,
+	}))
+
+	service := NewMessageService(new(MockMessageRepo), make(chan bool, 1), setupRedisClient(), &sync.Mutex{}, false,
+		WithSettingRepo(settings))
+	go service.FollowDesiredState(ctx)
+
+	assert.Eventually(t, func() bool {
+		return service.Status().DesiredState.State == StateStopped
+	}, 2*time.Second, 10*time.Millisecond)
+
+	status := service.Status()
+	assert.False(t, status.Running)
+	assert.Equal(t, "alice", status.DesiredState.ChangedBy)
+	assert.Equal(t, changedAt, *status.DesiredState.ChangedAt)
+}
+
+func TestStartupWithoutStoredState(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	service := NewMessageService(new(MockMessageRepo), make(chan bool, 1), setupRedisClient(), &sync.Mutex{}, false,
+		WithSettingRepo(&stubSettingRepo{}))
+	go service.FollowDesiredState(ctx)
+
+	assert.Eventually(t, func() bool { return service.Status().Running }, 2*time.Second, 10*time.Millisecond)
+	service.StopProcess()
+}
+
+func TestRequestStopIsStoredWhenBroadcastFails(t *testing.T) {
+	redisClient := redis.NewClient(&redis.Options{Addr: "localhost:1"})
+	ctx := context.Background()
+	settings := &stubSettingRepo{}
+
+	service := NewMessageService(new(MockMessageRepo), make(chan bool, 1), redisClient, &sync.Mutex{}, false,
+		WithSettingRepo(settings))
+
+	state, err := service.RequestStop(ctx, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, StateStopped, state.State)
+
+	setting, err := settings.Get(ctx, processingStateKey)
+	assert.NoError(t, err)
+	assert.Equal(t, StateStopped, setting.Value)
+
+	// without a store the broadcast is all there is
+	service = NewMessageService(new(MockMessageRepo), make(chan bool, 1), redisClient, &sync.Mutex{}, false)
+	_, err = service.RequestStop(ctx, "alice")
+	assert.Error(t, err)
+}
+
+func TestRequestStopReachesEveryInstance(t *testing.T) {
+	redisClient := setupRedisClient()
+	if err := redisClient.Ping(context.Background()).Err(); err != nil {
+		t.Skip("redis is not available:", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	settings := &stubSettingRepo{}
+	newInstance := func() *MessageService {
+		service := NewMessageService(new(MockMessageRepo), make(chan bool, 1), redisClient, &sync.Mutex{}, false,
+			WithSettingRepo(settings))
+		go service.FollowDesiredState(ctx)
+		return service
+	}
+
+	first, second := newInstance(), newInstance()
+	running := func(want bool) func() bool {
+		return func() bool {
+			return first.Status().Running == want && second.Status().Running == want
+		}
+	}
+	assert.Eventually(t, running(true), 2*time.Second, 10*time.Millisecond)
+
+	state, err := first.RequestStop(ctx, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, StateStopped, state.State)
+	assert.Eventually(t, running(false), 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "alice", second.Status().DesiredState.ChangedBy)
+
+	// an instance started later honours the stored state
+	late := newInstance()
+	time.Sleep(200 * time.Millisecond)
+	assert.False(t, late.Status().Running)
+}
+
+func TestSetRateIsStored(t *testing.T) {
+	ctx := context.Background()
+	settings := &stubSettingRepo{}
+
+	service := NewMessageService(new(MockMessageRepo), make(chan bool, 1), setupRedisClient(), &sync.Mutex{}, false,
+		WithSettingRepo(settings))
+	assert.NoError(t, service.SetRate(ctx, 30*time.Second, 10))
+
+	setting, err := settings.Get(ctx, processingRateKey)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"interval":"30s","batch_size":10}`, setting.Value)
+
+	// an instance started later picks up the stored rate
+	late := NewMessageService(new(MockMessageRepo), make(chan bool, 1), setupRedisClient(), &sync.Mutex{}, false,
+		WithSettingRepo(settings))
+	late.syncRate(ctx)
+
+	interval, batchSize := late.Rate()
+	assert.Equal(t, 30*time.Second, interval)
+	assert.Equal(t, 10, batchSize)
+}
+
+func TestInvalidStoredRateIsIgnored(t *testing.T) {
+	ctx := context.Background()
+	settings := &stubSettingRepo{}
+	assert.NoError(t, settings.Save(ctx, entity.Setting{
+		Key:   processingRateKey,
+		Value: `{"interval":"1ms","batch_size":10}`,
+	}))
+
+	service := NewMessageService(new(MockMessageRepo), make(chan bool, 1), setupRedisClient(), &sync.Mutex{}, false,
+		WithSettingRepo(settings))
+	before, beforeBatch := service.Rate()
+	service.syncRate(ctx)
+
+	interval, batchSize := service.Rate()
+	assert.Equal(t, before, interval)
+	assert.Equal(t, beforeBatch, batchSize)
+}
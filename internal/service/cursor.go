@@ -0,0 +1,27 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/busragumusel/insider-case/internal/entity"
+	"github.com/busragumusel/insider-case/internal/model"
+)
+
+func encodeCursor(message entity.Message) string {
+	data, _ := json.Marshal(model.Cursor{CreatedAt: message.CreatedAt, ID: message.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string) (*model.Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, &ValidationError{Field: "cursor", Message: "invalid cursor"}
+	}
+
+	var c model.Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == 0 {
+		return nil, &ValidationError{Field: "cursor", Message: "invalid cursor"}
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/busragumusel/insider-case/internal/entity"
+	"github.com/busragumusel/insider-case/internal/model"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// deliveryStatuses maps the statuses providers report, including the SMPP
+// receipt states, to ours.
+var deliveryStatuses = map[string]string{
+	"delivered":   entity.StatusDelivered,
+	"delivrd":     entity.StatusDelivered,
+	"undelivered": entity.StatusUndelivered,
+	"undeliv":     entity.StatusUndelivered,
+	"failed":      entity.StatusUndelivered,
+	"rejected":    entity.StatusUndelivered,
+	"rejectd":     entity.StatusUndelivered,
+	"expired":     entity.StatusUndelivered,
+	"deleted":     entity.StatusUndelivered,
+}
+
+// interimStatuses are the statuses providers report before the final one,
+// including the SMPP receipt states. They leave the message as it is.
+var interimStatuses = map[string]bool{
+	"accepted":  true,
+	"acceptd":   true,
+	"enroute":   true,
+	"buffered":  true,
+	"queued":    true,
+	"submitted": true,
+	"sent":      true,
+	"unknown":   true,
+}
+
+// RecordDelivery applies a provider's delivery report to the message it was
+// sent for. The first final report decides the outcome; interim reports and
+// later ones for the same message are accepted but don't change it.
+func (s *MessageService) RecordDelivery(ctx context.Context, report model.DeliveryReport) (model.MessageDetail, error) {
+	if report.Provider == "" {
+		return model.MessageDetail{}, &ValidationError{Field: "provider", Message: "provider must not be empty"}
+	}
+	if strings.TrimSpace(report.MessageID) == "" {
+		return model.MessageDetail{}, &ValidationError{Field: "messageId", Message: "messageId must not be empty"}
+	}
+
+	reported := strings.ToLower(report.Status)
+	if interimStatuses[reported] {
+		return s.deliveryDetail(ctx, report)
+	}
+
+	status, ok := deliveryStatuses[reported]
+	if !ok {
+		return model.MessageDetail{}, &ValidationError{Field: "status", Message: "status must be a known delivery status"}
+	}
+
+	var deliveredAt time.Time
+	if status == entity.StatusDelivered {
+		deliveredAt = time.Now()
+		if report.DeliveredAt != nil {
+			deliveredAt = *report.DeliveredAt
+		}
+	}
+
+	if _, err := s.repo.RecordDelivery(ctx, report.Provider, report.MessageID, status, deliveredAt, report.Error); err != nil {
+		return model.MessageDetail{}, errors.New("failed to record delivery")
+	}
+
+	return s.deliveryDetail(ctx, report)
+}
+
+func (s *MessageService) deliveryDetail(ctx context.Context, report model.DeliveryReport) (model.MessageDetail, error) {
+	message, err := s.repo.GetByProviderMessageID(ctx, report.Provider, report.MessageID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.MessageDetail{}, ErrMessageNotFound
+	}
+	if err != nil {
+		return model.MessageDetail{}, errors.New("failed to retrieve message")
+	}
+
+	return s.detail(ctx, message), nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/busragumusel/insider-case/internal/entity"
+	"github.com/busragumusel/insider-case/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestRecordDelivery(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	deliveredAt := time.Date(2025, 1, 1, 10, 0, 5, 0, time.UTC)
+	mockRepo.On("RecordDelivery", ctx, "primary", "provider-1", entity.StatusDelivered, deliveredAt, "").Return(int64(1), nil)
+	mockRepo.On("GetByProviderMessageID", ctx, "primary", "provider-1").Return(entity.Message{
+		ID:                1,
+		Status:            entity.StatusDelivered,
+		DeliveredAt:       deliveredAt,
+		ProviderMessageID: "provider-1",
+	}, nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	detail, err := service.RecordDelivery(ctx, model.DeliveryReport{
+		Provider:    "primary",
+		MessageID:   "provider-1",
+		Status:      "DELIVRD",
+		DeliveredAt: &deliveredAt,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, entity.StatusDelivered, detail.Status)
+	assert.Equal(t, deliveredAt, detail.DeliveredAt)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRecordDeliveryUndelivered(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	mockRepo.On("RecordDelivery", ctx, "primary", "provider-1", entity.StatusUndelivered, time.Time{}, "handset unreachable").
+		Return(int64(1), nil)
+	mockRepo.On("GetByProviderMessageID", ctx, "primary", "provider-1").Return(entity.Message{
+		ID:                1,
+		Status:            entity.StatusUndelivered,
+		ProviderMessageID: "provider-1",
+	}, nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	detail, err := service.RecordDelivery(ctx, model.DeliveryReport{
+		Provider:  "primary",
+		MessageID: "provider-1",
+		Status:    "undelivered",
+		Error:     "handset unreachable",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, entity.StatusUndelivered, detail.Status)
+}
+
+func TestRecordDeliveryDuplicate(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	mockRepo.On("RecordDelivery", ctx, "primary", "provider-1", entity.StatusUndelivered, time.Time{}, "").Return(int64(0), nil)
+	mockRepo.On("GetByProviderMessageID", ctx, "primary", "provider-1").Return(entity.Message{
+		ID:                1,
+		Status:            entity.StatusDelivered,
+		ProviderMessageID: "provider-1",
+	}, nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	detail, err := service.RecordDelivery(ctx, model.DeliveryReport{Provider: "primary", MessageID: "provider-1", Status: "undelivered"})
+	assert.NoError(t, err)
+	assert.Equal(t, entity.StatusDelivered, detail.Status)
+}
+
+func TestRecordDeliveryInterimStatus(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	mockRepo.On("GetByProviderMessageID", ctx, "primary", "provider-1").Return(entity.Message{
+		ID:                1,
+		Status:            entity.StatusSent,
+		ProviderMessageID: "provider-1",
+	}, nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	for _, status := range []string{"ENROUTE", "ACCEPTD", "buffered", "UNKNOWN"} {
+		detail, err := service.RecordDelivery(ctx, model.DeliveryReport{
+			Provider:  "primary",
+			MessageID: "provider-1",
+			Status:    status,
+		})
+		assert.NoError(t, err, status)
+		assert.Equal(t, entity.StatusSent, detail.Status, status)
+	}
+	mockRepo.AssertNotCalled(t, "RecordDelivery", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRecordDeliveryInvalid(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	mockRepo.On("RecordDelivery", ctx, "primary", "unknown", entity.StatusDelivered, mock.Anything, "").Return(int64(0), nil)
+	mockRepo.On("GetByProviderMessageID", ctx, "primary", "unknown").Return(entity.Message{}, gorm.ErrRecordNotFound)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	_, err := service.RecordDelivery(ctx, model.DeliveryReport{Provider: "primary", MessageID: "unknown", Status: "delivered"})
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+
+	var validationErr *ValidationError
+	_, err = service.RecordDelivery(ctx, model.DeliveryReport{Provider: "primary", MessageID: "provider-1", Status: "lost"})
+	assert.ErrorAs(t, err, &validationErr)
+
+	_, err = service.RecordDelivery(ctx, model.DeliveryReport{Provider: "primary", Status: "delivered"})
+	assert.ErrorAs(t, err, &validationErr)
+
+	_, err = service.RecordDelivery(ctx, model.DeliveryReport{MessageID: "provider-1", Status: "delivered"})
+	assert.ErrorAs(t, err, &validationErr)
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"github.com/busragumusel/insider-case/internal/entity"
+	"github.com/busragumusel/insider-case/internal/model"
+	"io"
+	"log"
+	"strings"
+)
+
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+
+	importChunkSize  = 500
+	maxNDJSONLineLen = 64 * 1024
+
+	utf8BOM = "\ufeff"
+)
+
+var ErrUnsupportedImportFormat = errors.New("unsupported import format")
+
+// rowReader yields one message request per call. A non-nil rowErr rejects only
+// the current row, while a non-nil err aborts the whole import (io.EOF at the end).
+type rowReader interface {
+	Next() (row int, req model.MessageRequest, rowErr *model.ImportError, err error)
+}
+
+func (s *MessageService) Import(ctx context.Context, r io.Reader, format string) (model.ImportReport, error) {
+	var rows rowReader
+	switch format {
+	case ImportFormatCSV:
+		csvRows, err := newCSVRowReader(r)
+		if err != nil {
+			return model.ImportReport{}, err
+		}
+		rows = csvRows
+	case ImportFormatNDJSON:
+		rows = newNDJSONRowReader(r)
+	default:
+		return model.ImportReport{}, ErrUnsupportedImportFormat
+	}
+
+	report := model.ImportReport{Errors: []model.ImportError{}}
+	chunk := make([]entity.Message, 0, importChunkSize)
+	chunkRows := make([]int, 0, importChunkSize)
+
+	reject := func(importErr model.ImportError) {
+		report.Rejected++
+		report.Errors = append(report.Errors, importErr)
+	}
+
+	flush := func() {
+		if len(chunk) == 0 {
+			return
+		}
+
+		if err := s.repo.CreateBatch(ctx, chunk); err != nil {
+			log.Printf("Failed to import chunk of %d messages: %v", len(chunk), err)
+			for _, row := range chunkRows {
+				reject(model.ImportError{Row: row, Message: "failed to store message"})
+			}
+		} else {
+			report.Imported += len(chunk)
+		}
+
+		chunk = chunk[:0]
+		chunkRows = chunkRows[:0]
+	}
+
+	for {
+		row, req, rowErr, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			flush()
+			return report, err
+		}
+
+		report.Total++
+
+		if rowErr != nil {
+			reject(*rowErr)
+			continue
+		}
+
+		req = normalizeMessage(req)
+		if err := validateMessage(req); err != nil {
+			var validationErr *ValidationError
+			errors.As(err, &validationErr)
+			reject(model.ImportError{Row: row, Field: validationErr.Field, Message: validationErr.Message})
+			continue
+		}
+
+		chunk = append(chunk, entity.Message{
+			PhoneNumber: req.To,
+			Content:     req.Content,
+			Status:      entity.StatusPending,
+		})
+		chunkRows = append(chunkRows, row)
+
+		if len(chunk) == importChunkSize {
+			flush()
+		}
+	}
+
+	flush()
+
+	return report, nil
+}
+
+type csvRowReader struct {
+	reader     *csv.Reader
+	row        int
+	toIdx      int
+	contentIdx int
+}
+
+func newCSVRowReader(r io.Reader) (*csvRowReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("csv body is empty")
+	}
+	if err != nil {
+		return nil, errors.New("failed to read csv header")
+	}
+
+	// spreadsheet exports often start with a byte order mark
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], utf8BOM)
+	}
+
+	rr := &csvRowReader{reader: reader, toIdx: -1, contentIdx: -1}
+	for i, column := range header {
+		switch strings.ToLower(strings.TrimSpace(column)) {
+		case "to":
+			rr.toIdx = i
+		case "content":
+			rr.contentIdx = i
+		}
+	}
+
+	if rr.toIdx == -1 || rr.contentIdx == -1 {
+		return nil, errors.New("csv header must contain to and content columns")
+	}
+
+	return rr, nil
+}
+
+func (r *csvRowReader) Next() (int, model.MessageRequest, *model.ImportError, error) {
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return 0, model.MessageRequest{}, nil, io.EOF
+	}
+
+	r.row++
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return r.row, model.MessageRequest{}, &model.ImportError{Row: r.row, Message: parseErr.Err.Error()}, nil
+	}
+	if err != nil {
+		return r.row, model.MessageRequest{}, nil, err
+	}
+
+	if len(record) <= r.toIdx || len(record) <= r.contentIdx {
+		return r.row, model.MessageRequest{}, &model.ImportError{Row: r.row, Message: "missing columns"}, nil
+	}
+
+	return r.row, model.MessageRequest{
+		To:      record[r.toIdx],
+		Content: record[r.contentIdx],
+	}, nil, nil
+}
+
+type ndjsonRowReader struct {
+	scanner *bufio.Scanner
+	row     int
+}
+
+func newNDJSONRowReader(r io.Reader) *ndjsonRowReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxNDJSONLineLen)
+
+	return &ndjsonRowReader{scanner: scanner}
+}
+
+func (r *ndjsonRowReader) Next() (int, model.MessageRequest, *model.ImportError, error) {
+	for r.scanner.Scan() {
+		line := strings.TrimSpace(r.scanner.Text())
+		if r.row == 0 {
+			line = strings.TrimPrefix(line, utf8BOM)
+		}
+		if line == "" {
+			continue
+		}
+
+		r.row++
+
+		var req model.MessageRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			return r.row, model.MessageRequest{}, &model.ImportError{Row: r.row, Message: "invalid json"}, nil
+		}
+
+		return r.row, req, nil, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return r.row, model.MessageRequest{}, nil, err
+	}
+
+	return 0, model.MessageRequest{}, nil, io.EOF
+}
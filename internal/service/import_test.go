@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/busragumusel/insider-case/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestImportCSV(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	mockRepo.On("CreateBatch", ctx, mock.MatchedBy(func(messages []entity.Message) bool {
+		return len(messages) == 2 && messages[0].PhoneNumber == "+905551111111"
+	})).Return(nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	body := "content,to\n" +
+		"Hello,+905551111111\n" +
+		"Hello,invalid\n" +
+		",+905552222222\n" +
+		"\"Hi, there\",+905553333333\n"
+
+	report, err := service.Import(ctx, strings.NewReader(body), ImportFormatCSV)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, report.Total)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 2, report.Rejected)
+	assert.Equal(t, 2, report.Errors[0].Row)
+	assert.Equal(t, "to", report.Errors[0].Field)
+	assert.Equal(t, 3, report.Errors[1].Row)
+	assert.Equal(t, "content", report.Errors[1].Field)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestImportCSVMissingHeader(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	var mu sync.Mutex
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	_, err := service.Import(context.Background(), strings.NewReader("phone,text\n"), ImportFormatCSV)
+	assert.Error(t, err)
+}
+
+func TestImportCSVWithByteOrderMark(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	mockRepo.On("CreateBatch", ctx, mock.MatchedBy(func(messages []entity.Message) bool {
+		return len(messages) == 1 && messages[0].PhoneNumber == "+905551111111"
+	})).Return(nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	report, err := service.Import(ctx, strings.NewReader("\ufeffto,content\n +905551111111 ,Hello\n"), ImportFormatCSV)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestImportNDJSON(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	mockRepo.On("CreateBatch", ctx, mock.MatchedBy(func(messages []entity.Message) bool {
+		return len(messages) == 2 && messages[0].PhoneNumber == "+905551111111"
+	})).Return(nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	body := "\ufeff" + `{"to":" +905551111111 ","content":"Hello"}` + "\n\n" +
+		`{"to":"+905552222222"` + "\n" +
+		`{"to":"+905553333333","content":"World"}` + "\n"
+
+	report, err := service.Import(ctx, strings.NewReader(body), ImportFormatNDJSON)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Total)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 1, report.Rejected)
+	assert.Equal(t, 2, report.Errors[0].Row)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestImportChunksAndReportsFailedChunk(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	mockRepo.On("CreateBatch", ctx, mock.AnythingOfType("[]entity.Message")).Return(nil).Once()
+	mockRepo.On("CreateBatch", ctx, mock.AnythingOfType("[]entity.Message")).Return(errors.New("DB error")).Once()
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	var body strings.Builder
+	body.WriteString("to,content\n")
+	for i := 0; i < importChunkSize+10; i++ {
+		fmt.Fprintf(&body, "+90555%07d,Hello\n", i)
+	}
+
+	report, err := service.Import(ctx, strings.NewReader(body.String()), ImportFormatCSV)
+	assert.NoError(t, err)
+	assert.Equal(t, importChunkSize+10, report.Total)
+	assert.Equal(t, importChunkSize, report.Imported)
+	assert.Equal(t, 10, report.Rejected)
+	mockRepo.AssertNumberOfCalls(t, "CreateBatch", 2)
+}
+
+func TestImportUnsupportedFormat(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	var mu sync.Mutex
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	_, err := service.Import(context.Background(), strings.NewReader(""), "xml")
+	assert.ErrorIs(t, err, ErrUnsupportedImportFormat)
+}
@@ -0,0 +1,14 @@
+package service
+
+// Leadership tells whether this instance is the one that runs the schedule,
+// such as leader.Elector.
+type Leadership interface {
+	IsLeader() bool
+	LeaderID() string
+}
+
+// leading reports whether this instance runs the schedule. Without leader
+// election every instance does.
+func (s *MessageService) leading() bool {
+	return s.leadership == nil || s.leadership.IsLeader()
+}
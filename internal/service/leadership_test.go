@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/busragumusel/insider-case/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type stubLeadership struct {
+	leader atomic.Bool
+}
+
+func (l *stubLeadership) IsLeader() bool {
+	return l.leader.Load()
+}
+
+func (l *stubLeadership) LeaderID() string {
+	if l.leader.Load() {
+		return "worker-1"
+	}
+	return "worker-2"
+}
+
+func TestProcessRunsOnlyOnLeader(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	claimed := make(chan struct{}, 1)
+	mockRepo.On("RenewClaim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("ClaimPending", mock.Anything, defaultBatchSize, mock.Anything).
+		Run(func(mock.Arguments) {
+			select {
+			case claimed <- struct{}{}:
+			default:
+			}
+		}).
+		Return([]entity.Message{}, nil)
+
+	leadership := &stubLeadership{}
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false,
+		WithWorkerID("worker-1"),
+		WithLeadership(leadership),
+		WithRate(time.Second, defaultBatchSize),
+		WithRunOnStart(true))
+
+	status := service.Status()
+	assert.False(t, status.Leader)
+	assert.Equal(t, "worker-2", status.LeaderID)
+
+	service.StartProcess(ctx)
+	defer service.StopProcess()
+
+	select {
+	case <-claimed:
+		t.Fatal("a follower must not process messages")
+	case <-time.After(1500 * time.Millisecond):
+	}
+
+	leadership.leader.Store(true)
+
+	select {
+	case <-claimed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the leader did not process messages")
+	}
+
+	status = service.Status()
+	assert.True(t, status.Leader)
+	assert.Equal(t, "worker-1", status.LeaderID)
+}
@@ -1,30 +1,59 @@
 package service
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/busragumusel/insider-case/internal/entity"
 	"github.com/busragumusel/insider-case/internal/model"
 	"github.com/busragumusel/insider-case/internal/repository"
+	"github.com/busragumusel/insider-case/internal/sender"
 	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+var (
+	ErrMessageNotFound      = errors.New("message not found")
+	ErrProvidersUnavailable = errors.New("all providers are unavailable")
+	ErrRateLimited          = errors.New("rate limited by provider")
+)
+
 const (
-	processTimeRange      = 2
-	messageCountPerMinute = 2
+	defaultProcessInterval = 2 * time.Minute
+	defaultBatchSize       = 2
+
+	minProcessInterval = time.Second
+	maxBatchSize       = 1000
+
+	defaultVisibilityTimeout = 5 * time.Minute
+	minVisibilityTimeout     = 10 * time.Second
+
+	defaultPageSize = 100
+	maxPageSize     = 1000
 )
 
 type MessageSvc interface {
 	StartProcess(ctx context.Context)
 	StopProcess()
-	Retrieve(ctx context.Context, status string) ([]entity.Message, error)
+	RequestStart(ctx context.Context, changedBy string) (model.ProcessingState, error)
+	RequestStop(ctx context.Context, changedBy string) (model.ProcessingState, error)
+	Retrieve(ctx context.Context, filter model.MessageFilter) ([]entity.Message, string, error)
+	Create(ctx context.Context, req model.MessageRequest) (entity.Message, error)
+	Import(ctx context.Context, r io.Reader, format string) (model.ImportReport, error)
+	Get(ctx context.Context, id uint) (model.MessageDetail, error)
+	GetByProviderMessageID(ctx context.Context, providerName string, providerMessageID string) (model.MessageDetail, error)
+	Rate() (time.Duration, int)
+	SetRate(ctx context.Context, interval time.Duration, batchSize int) error
+	ProcessNow(ctx context.Context) ([]model.ProcessResult, error)
+	Status() model.ProcessStatus
+	RecordDelivery(ctx context.Context, report model.DeliveryReport) (model.MessageDetail, error)
 }
 
 type MessageService struct {
@@ -33,6 +62,26 @@ type MessageService struct {
 	redisClient *redis.Client
 	mu          *sync.Mutex
 	running     bool
+	retryPolicy RetryPolicy
+	workerID    string
+
+	visibilityTimeout time.Duration
+
+	interval    time.Duration
+	batchSize   int
+	rateChanged chan struct{}
+	runOnStart  bool
+	concurrency int
+	router      *sender.Router
+	httpClient  *http.Client
+	cancelRun   context.CancelFunc
+	runDone     chan struct{}
+	leadership  Leadership
+	settings    repository.SettingRepo
+	desired     model.ProcessingState
+
+	stats    processStats
+	throttle throttle
 }
 
 func NewMessageService(
@@ -41,18 +90,53 @@ func NewMessageService(
 	redisClient *redis.Client,
 	mu *sync.Mutex,
 	running bool,
+	opts ...Option,
 ) *MessageService {
 	if mu == nil {
 		mu = &sync.Mutex{}
 	}
 
-	return &MessageService{
-		repo,
-		stopChan,
-		redisClient,
-		mu,
-		running,
+	s := &MessageService{
+		repo:        repo,
+		stopChan:    stopChan,
+		redisClient: redisClient,
+		mu:          mu,
+		running:     running,
+		retryPolicy: DefaultRetryPolicy(),
+		workerID:    DefaultWorkerID(),
+
+		visibilityTimeout: defaultVisibilityTimeout,
+
+		interval:    defaultProcessInterval,
+		batchSize:   defaultBatchSize,
+		rateChanged: make(chan struct{}, 1),
+		concurrency: defaultConcurrency,
+		desired:     model.ProcessingState{State: StateRunning},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.router == nil {
+		s.router = sender.SingleRouter(sender.NewWebhookSender(sender.WebhookConfig{
+			URL:     os.Getenv("WEBHOOK_URL"),
+			AuthKey: os.Getenv("AUTH_KEY"),
+		}, s.httpClient))
 	}
+
+	return s
+}
+
+// DefaultWorkerID identifies this instance when claiming messages and
+// electing a leader.
+func DefaultWorkerID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
 }
 
 func (s *MessageService) StartProcess(ctx context.Context) {
@@ -67,27 +151,75 @@ func (s *MessageService) StartProcess(ctx context.Context) {
 		s.stopChan = make(chan bool, 1)
 	}
 
+	// canceling the run aborts requests to providers that are in flight
+	ctx, cancel := context.WithCancel(ctx)
+	s.cancelRun = cancel
+	done := make(chan struct{})
+	s.runDone = done
+
 	s.running = true
+	interval := s.interval
+	startedAt := time.Now()
+	s.stats = processStats{
+		startedAt: startedAt,
+		nextRunAt: startedAt.Add(interval),
+	}
 	s.mu.Unlock()
 
-	ticker := time.NewTicker(processTimeRange * time.Minute)
+	ticker := time.NewTicker(interval)
+	reaperTicker := time.NewTicker(s.visibilityTimeout / 2)
 
 	go func() {
 		defer func() {
 			ticker.Stop()
+			reaperTicker.Stop()
+			s.recordStop()
 			s.mu.Lock()
+			cancel()
+			// StopProcess also cancels the run, so the loop may have ended
+			// without taking the stop signal
+			select {
+			case <-s.stopChan:
+			default:
+			}
+			s.cancelRun = nil
+			s.runDone = nil
 			s.running = false
 			s.mu.Unlock()
+			close(done)
 		}()
 
 		log.Println("Message processing started.")
 
+		if s.runOnStart && s.leading() {
+			if _, err := s.process(ctx); err != nil {
+				log.Println("Error processing messages:", err)
+			}
+		}
+
 		for {
 			select {
 			case <-ticker.C:
-				if err := s.process(ctx); err != nil {
+				interval, _ := s.Rate()
+				s.recordNextRun(time.Now().Add(interval))
+
+				if !s.leading() {
+					log.Println("Not the leader, skipping run.")
+					break
+				}
+
+				if _, err := s.process(ctx); err != nil {
 					log.Println("Error processing messages:", err)
 				}
+			case <-reaperTicker.C:
+				if s.leading() {
+					s.releaseExpiredClaims(ctx)
+				}
+			case <-s.rateChanged:
+				interval, _ := s.Rate()
+				ticker.Reset(interval)
+				s.recordNextRun(time.Now().Add(interval))
+				log.Printf("Processing interval changed to %s.", interval)
 			case <-s.stopChan:
 				log.Println("Stopping process...")
 				return
@@ -103,99 +235,481 @@ func (s *MessageService) StopProcess() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// a stop signal left behind would end the next run as soon as it starts
+	if !s.running {
+		log.Println("Process already stopped.")
+		return
+	}
+
 	select {
 	case s.stopChan <- true:
 		log.Println("Stop signal sent.")
 	default:
 		log.Println("Process already stopped.")
 	}
+
+	if s.cancelRun != nil {
+		s.cancelRun()
+	}
+}
+
+// Wait blocks until the processing loop has ended, e.g. after the context it
+// was started with was canceled.
+func (s *MessageService) Wait() {
+	s.mu.Lock()
+	done := s.runDone
+	s.mu.Unlock()
+
+	if done != nil {
+		<-done
+	}
 }
 
-func (s *MessageService) Retrieve(ctx context.Context, status string) ([]entity.Message, error) {
-	messages, err := s.repo.GetByStatus(ctx, status, 1000)
+// Rate returns how often messages are processed and how many are sent per run.
+func (s *MessageService) Rate() (time.Duration, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.interval, s.batchSize
+}
+
+// SetRate changes the processing rate of every instance, so that it applies
+// whichever one leads, and stores it so that it outlasts restarts. A running
+// process picks up the new interval right away, without being restarted.
+func (s *MessageService) SetRate(ctx context.Context, interval time.Duration, batchSize int) error {
+	if err := validateRate(interval, batchSize); err != nil {
+		return err
+	}
+
+	if err := s.broadcastRate(ctx, interval, batchSize); err != nil {
+		return err
+	}
+	s.applyRate(interval, batchSize)
+
+	return nil
+}
+
+func validateRate(interval time.Duration, batchSize int) error {
+	if interval < minProcessInterval {
+		return &ValidationError{
+			Field:   "interval",
+			Message: fmt.Sprintf("interval must be at least %s", minProcessInterval),
+		}
+	}
+	if batchSize <= 0 || batchSize > maxBatchSize {
+		return &ValidationError{
+			Field:   "batch_size",
+			Message: fmt.Sprintf("batch size must be between 1 and %d", maxBatchSize),
+		}
+	}
+
+	return nil
+}
+
+// applyRate changes the rate of this instance.
+func (s *MessageService) applyRate(interval time.Duration, batchSize int) {
+	s.mu.Lock()
+	if s.interval == interval && s.batchSize == batchSize {
+		s.mu.Unlock()
+		return
+	}
+	s.interval = interval
+	s.batchSize = batchSize
+	s.mu.Unlock()
+
+	select {
+	case s.rateChanged <- struct{}{}:
+	default:
+	}
+
+	log.Printf("Rate set to %d messages every %s.", batchSize, interval)
+}
+
+// Retrieve returns one page of messages matching the filter along with the
+// cursor of the next page, which is empty on the last page.
+func (s *MessageService) Retrieve(ctx context.Context, filter model.MessageFilter) ([]entity.Message, string, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	if filter.Limit > maxPageSize {
+		return nil, "", &ValidationError{
+			Field:   "limit",
+			Message: fmt.Sprintf("limit must be at most %d", maxPageSize),
+		}
+	}
+
+	var after *model.Cursor
+	if filter.Cursor != "" {
+		cursor, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		after = cursor
+	}
+
+	pageSize := filter.Limit
+	filter.Limit = pageSize + 1
+
+	messages, err := s.repo.List(ctx, filter, after)
 	if err != nil {
-		return nil, errors.New("failed to retrieve sent messages")
+		return nil, "", errors.New("failed to retrieve messages")
+	}
+
+	if len(messages) <= pageSize {
+		return messages, "", nil
 	}
 
-	return messages, nil
+	messages = messages[:pageSize]
+
+	return messages, encodeCursor(messages[pageSize-1]), nil
 }
 
-func (s *MessageService) process(ctx context.Context) error {
-	messages, err := s.repo.GetByStatus(ctx, entity.StatusPending, messageCountPerMinute)
+// Get returns the message together with the data the provider returned when
+// it was sent.
+func (s *MessageService) Get(ctx context.Context, id uint) (model.MessageDetail, error) {
+	message, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.MessageDetail{}, ErrMessageNotFound
+	}
 	if err != nil {
-		return errors.New("error occurred when getting messages")
+		return model.MessageDetail{}, errors.New("failed to retrieve message")
+	}
+
+	return s.detail(ctx, message), nil
+}
+
+// GetByProviderMessageID looks a message up by the provider that accepted it
+// and the id that provider assigned.
+func (s *MessageService) GetByProviderMessageID(
+	ctx context.Context,
+	providerName string,
+	providerMessageID string,
+) (model.MessageDetail, error) {
+	message, err := s.repo.GetByProviderMessageID(ctx, providerName, providerMessageID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.MessageDetail{}, ErrMessageNotFound
+	}
+	if err != nil {
+		return model.MessageDetail{}, errors.New("failed to retrieve message")
+	}
+
+	return s.detail(ctx, message), nil
+}
+
+func (s *MessageService) detail(ctx context.Context, message entity.Message) model.MessageDetail {
+	detail := model.MessageDetail{
+		Message:           message,
+		ProviderMessageID: message.ProviderMessageID,
+	}
+	if !message.SentAt.IsZero() {
+		detail.SendingTime = message.SentAt.Format(time.RFC3339)
+	}
+
+	// every message sent since the provider data is stored on the row names
+	// its provider, even when the provider returned no id; only messages sent
+	// before that have their provider message id in the cache alone
+	if message.Status != entity.StatusSent || message.ProviderName != "" {
+		return detail
+	}
+
+	cached, err := s.redisClient.HGetAll(ctx, messageCacheKey(message.ID)).Result()
+	if err != nil {
+		log.Printf("failed to read cache for message %d: %v", message.ID, err)
+		return detail
+	}
+
+	detail.ProviderMessageID = cached["message_id"]
+	if detail.SendingTime == "" {
+		detail.SendingTime = cached["sending_time"]
+	}
+
+	return detail
+}
+
+func (s *MessageService) Create(ctx context.Context, req model.MessageRequest) (entity.Message, error) {
+	req = normalizeMessage(req)
+	if err := validateMessage(req); err != nil {
+		return entity.Message{}, err
+	}
+
+	message := entity.Message{
+		PhoneNumber: req.To,
+		Content:     req.Content,
+		Status:      entity.StatusPending,
+	}
+
+	if err := s.repo.Create(ctx, &message); err != nil {
+		return entity.Message{}, errors.New("failed to create message")
+	}
+
+	return message, nil
+}
+
+// ProcessNow sends one batch right away, independently of the schedule.
+func (s *MessageService) ProcessNow(ctx context.Context) ([]model.ProcessResult, error) {
+	return s.process(ctx)
+}
+
+func (s *MessageService) process(ctx context.Context) ([]model.ProcessResult, error) {
+	results, err := s.processBatch(ctx)
+	s.recordRun(results, err)
+
+	return results, err
+}
+
+func (s *MessageService) processBatch(ctx context.Context) ([]model.ProcessResult, error) {
+	// don't claim messages that can't be sent anyway
+	if !s.router.Available() {
+		log.Println("All providers are unavailable, skipping run.")
+		return nil, ErrProvidersUnavailable
+	}
+
+	batchSize, pausedUntil := s.throttledRate()
+	if time.Now().Before(pausedUntil) {
+		log.Printf("Rate limited by provider, skipping run until %s.", pausedUntil.Format(time.RFC3339))
+		return nil, ErrRateLimited
+	}
+
+	messages, err := s.repo.ClaimPending(ctx, batchSize, s.workerID)
+	if err != nil {
+		return nil, errors.New("error occurred when getting messages")
 	}
 
 	if len(messages) == 0 {
 		log.Println("No pending messages to send.")
-		return nil
+		return []model.ProcessResult{}, nil
 	}
 
-	for _, msg := range messages {
-		res, err := s.sendToWebhook(ctx, model.Payload{
-			To:      msg.PhoneNumber,
-			Content: msg.Content,
-		})
-		if err != nil {
-			log.Println("Failed to send message:", err)
-			continue
+	results, rateLimited := s.sendBatch(ctx, messages)
+	if !rateLimited {
+		s.recordNoRateLimit()
+	}
+
+	return results, nil
+}
+
+// sendMessage sends a claimed message and records the outcome. rateLimited is
+// shared by the whole batch: once a provider rate limits us, the messages that
+// weren't sent yet go back to the queue.
+func (s *MessageService) sendMessage(ctx context.Context, msg entity.Message, rateLimited *atomic.Bool) model.ProcessResult {
+	if ctx.Err() != nil {
+		return model.ProcessResult{
+			ID:       msg.ID,
+			Status:   s.release(storeContext(ctx), msg),
+			Error:    ctx.Err().Error(),
+			Released: true,
+		}
+	}
+
+	// the rest of the batch waits for the provider to accept messages again
+	if rateLimited.Load() {
+		return model.ProcessResult{
+			ID:       msg.ID,
+			Status:   s.release(ctx, msg),
+			Error:    ErrRateLimited.Error(),
+			Released: true,
 		}
+	}
 
-		log.Printf("messageID: %s", res.MessageID)
+	// the claim may have expired while the message waited for its turn in the
+	// batch, and another worker could then be sending it already
+	if err := s.repo.RenewClaim(ctx, msg.ID, s.workerID); err != nil {
+		log.Printf("Not sending message with ID %d, its claim could not be renewed: %v", msg.ID, err)
+		status := msg.Status
+		if !errors.Is(err, repository.ErrClaimLost) {
+			status = s.release(storeContext(ctx), msg)
+		}
+		return model.ProcessResult{
+			ID:       msg.ID,
+			Status:   status,
+			Error:    err.Error(),
+			Released: true,
+		}
+	}
 
-		err = s.repo.Update(ctx, msg.ID, entity.StatusSent)
-		if err != nil {
-			log.Printf("Failed to update message with ID %d: %v", msg.ID, err)
+	// the send, including waiting for a rate limit token, has to end well
+	// before the renewed claim expires
+	sendCtx, cancel := context.WithTimeout(ctx, s.visibilityTimeout/2)
+	defer cancel()
+
+	res, provider, err := s.router.Send(sendCtx, model.Payload{
+		To:      msg.PhoneNumber,
+		Content: msg.Content,
+	})
+	// a canceled run still has to record what happened to the message
+	storeCtx := storeContext(ctx)
+
+	if err != nil && sendCtx.Err() != nil {
+		return model.ProcessResult{
+			ID:       msg.ID,
+			Status:   s.release(storeCtx, msg),
+			Provider: provider,
+			Error:    err.Error(),
+			Released: true,
+		}
+	}
+	if retryAfter, ok := sender.RetryAfter(err); ok {
+		// workers rate limited at the same time only slow down once
+		if rateLimited.CompareAndSwap(false, true) {
+			s.recordRateLimit(retryAfter)
+		}
+		return model.ProcessResult{
+			ID:       msg.ID,
+			Status:   s.release(storeCtx, msg),
+			Provider: provider,
+			Error:    err.Error(),
+			Released: true,
+		}
+	}
+	if sender.IsLimiterError(err) {
+		log.Println("Failed to take a rate limit token:", err)
+		return model.ProcessResult{
+			ID:       msg.ID,
+			Status:   s.release(storeCtx, msg),
+			Provider: provider,
+			Error:    err.Error(),
+			Released: true,
+		}
+	}
+	if errors.Is(err, sender.ErrCircuitOpen) {
+		return model.ProcessResult{
+			ID:       msg.ID,
+			Status:   s.release(storeCtx, msg),
+			Error:    err.Error(),
+			Released: true,
+		}
+	}
+	if err != nil {
+		log.Println("Failed to send message:", err)
+		return model.ProcessResult{
+			ID:       msg.ID,
+			Status:   s.handleFailure(storeCtx, msg, err),
+			Provider: provider,
+			Error:    err.Error(),
 		}
-		log.Printf("Message sent! ID: %d\n", msg.ID)
 	}
 
-	return nil
+	log.Printf("messageID: %s, provider: %s", res.MessageID, provider)
+
+	s.saveToCache(storeCtx, msg.ID, provider, res)
+
+	err = s.repo.MarkSent(storeCtx, msg.ID, s.workerID, provider, res)
+	if errors.Is(err, repository.ErrClaimLost) {
+		log.Printf("Message with ID %d was sent after its claim expired, leaving it to the worker that holds it now.", msg.ID)
+	} else if err != nil {
+		log.Printf("Failed to update message with ID %d: %v", msg.ID, err)
+	}
+	log.Printf("Message sent! ID: %d\n", msg.ID)
+
+	return model.ProcessResult{
+		ID:                msg.ID,
+		Status:            entity.StatusSent,
+		Provider:          provider,
+		ProviderMessageID: res.MessageID,
+	}
 }
 
-func (s *MessageService) sendToWebhook(ctx context.Context, payload model.Payload) (model.Response, error) {
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return model.Response{}, err
+// storeContext returns a context for recording the outcome of a send. Once ctx
+// is canceled it returns one that isn't, so that a message accepted by a
+// provider right before a stop is still marked as sent.
+func storeContext(ctx context.Context) context.Context {
+	if ctx.Err() != nil {
+		return context.WithoutCancel(ctx)
 	}
 
-	req, err := http.NewRequest("POST", os.Getenv("WEBHOOK_URL"), bytes.NewBuffer(jsonData))
+	return ctx
+}
+
+// releaseExpiredClaims puts messages stuck in processing, e.g. because the
+// worker that claimed them crashed, back into the queue.
+func (s *MessageService) releaseExpiredClaims(ctx context.Context) {
+	released, err := s.repo.ReleaseExpiredClaims(ctx, time.Now().Add(-s.visibilityTimeout), s.retryPolicy.MaxAttempts)
 	if err != nil {
-		return model.Response{}, err
+		log.Println("Error releasing expired claims:", err)
+		return
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-ins-auth-key", os.Getenv("AUTH_KEY"))
-
-	client := &http.Client{Timeout: 10 * time.Second}
+	if released > 0 {
+		log.Printf("Released %d messages with expired claims.", released)
+	}
+}
 
-	resp, err := client.Do(req)
+// release puts a message that wasn't attempted back into the queue without
+// counting an attempt.
+func (s *MessageService) release(ctx context.Context, msg entity.Message) string {
+	err := s.repo.Release(ctx, msg.ID, s.workerID)
+	if errors.Is(err, repository.ErrClaimLost) {
+		log.Printf("Claim on message with ID %d expired before it was released.", msg.ID)
+		return msg.Status
+	}
 	if err != nil {
-		return model.Response{}, err
+		log.Printf("Failed to release message with ID %d: %v", msg.ID, err)
+		return msg.Status
+	}
+
+	return entity.StatusPending
+}
+
+// handleFailure either schedules the message for another attempt or, when the
+// error is permanent or attempts are exhausted, marks it as failed. It returns
+// the status the message ends up in.
+func (s *MessageService) handleFailure(ctx context.Context, msg entity.Message, sendErr error) string {
+	attempts := msg.AttemptCount + 1
+
+	status := entity.StatusPending
+	var nextAttemptAt time.Time
+	if sender.IsPermanent(sendErr) || attempts >= s.retryPolicy.MaxAttempts {
+		status = entity.StatusFailed
+	} else {
+		nextAttemptAt = time.Now().Add(s.retryPolicy.Backoff(attempts))
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusAccepted {
-		return model.Response{}, errors.New("failed to send request: " + resp.Status)
+	err := s.repo.RecordFailure(ctx, msg.ID, s.workerID, status, sendErr.Error(), nextAttemptAt)
+	if errors.Is(err, repository.ErrClaimLost) {
+		log.Printf("Claim on message with ID %d expired before its failure was recorded.", msg.ID)
+		return msg.Status
+	}
+	if err != nil {
+		log.Printf("Failed to record failure of message with ID %d: %v", msg.ID, err)
+		return msg.Status
 	}
 
-	var response model.Response
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return model.Response{}, err
+	if status == entity.StatusFailed {
+		log.Printf("Message failed permanently after %d attempts! ID: %d", attempts, msg.ID)
+		return status
 	}
 
-	s.saveToCache(ctx, response)
+	log.Printf("Message will be retried at %s. ID: %d", nextAttemptAt.Format(time.RFC3339), msg.ID)
 
-	return response, nil
+	return status
 }
 
-func (s *MessageService) saveToCache(ctx context.Context, response model.Response) {
+func (s *MessageService) saveToCache(ctx context.Context, id uint, provider string, response model.Response) {
 	sendingTime := time.Now().Format(time.RFC3339)
-	key := "message_id:" + response.MessageID
-	err := s.redisClient.HSet(ctx, key, map[string]interface{}{
+
+	pipe := s.redisClient.TxPipeline()
+	// not every provider returns an id
+	if response.MessageID != "" {
+		pipe.HSet(ctx, providerCacheKey(response.MessageID), map[string]interface{}{
+			"sending_time": sendingTime,
+			"id":           id,
+		})
+	}
+	pipe.HSet(ctx, messageCacheKey(id), map[string]interface{}{
+		"message_id":   response.MessageID,
+		"provider":     provider,
 		"sending_time": sendingTime,
-	}).Err()
-	if err != nil {
+	})
+
+	if _, err := pipe.Exec(ctx); err != nil {
 		log.Printf("failed to cache: %v", err)
 	}
 }
+
+func providerCacheKey(messageID string) string {
+	return "message_id:" + messageID
+}
+
+func messageCacheKey(id uint) string {
+	return fmt.Sprintf("message:%d", id)
+}
@@ -3,14 +3,22 @@ package service
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/busragumusel/insider-case/internal/entity"
+	"github.com/busragumusel/insider-case/internal/model"
+	"github.com/busragumusel/insider-case/internal/repository"
+	"github.com/busragumusel/insider-case/internal/sender"
 	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 type MockMessageRepo struct {
@@ -22,11 +30,90 @@ func (m *MockMessageRepo) GetByStatus(ctx context.Context, status string, limit
 	return args.Get(0).([]entity.Message), args.Error(1)
 }
 
+func (m *MockMessageRepo) List(ctx context.Context, filter model.MessageFilter, after *model.Cursor) ([]entity.Message, error) {
+	args := m.Called(ctx, filter, after)
+	return args.Get(0).([]entity.Message), args.Error(1)
+}
+
+func (m *MockMessageRepo) GetByID(ctx context.Context, id uint) (entity.Message, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(entity.Message), args.Error(1)
+}
+
+func (m *MockMessageRepo) ClaimPending(ctx context.Context, limit int, workerID string) ([]entity.Message, error) {
+	args := m.Called(ctx, limit, workerID)
+	return args.Get(0).([]entity.Message), args.Error(1)
+}
+
+func (m *MockMessageRepo) ReleaseExpiredClaims(ctx context.Context, claimedBefore time.Time, maxAttempts int) (int64, error) {
+	args := m.Called(ctx, claimedBefore, maxAttempts)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockMessageRepo) MarkSent(ctx context.Context, id uint, workerID string, providerName string, response model.Response) error {
+	args := m.Called(ctx, id, workerID, providerName, response)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepo) RecordDelivery(
+	ctx context.Context,
+	providerName string,
+	providerMessageID string,
+	status string,
+	deliveredAt time.Time,
+	reason string,
+) (int64, error) {
+	args := m.Called(ctx, providerName, providerMessageID, status, deliveredAt, reason)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockMessageRepo) GetByProviderMessageID(
+	ctx context.Context,
+	providerName string,
+	providerMessageID string,
+) (entity.Message, error) {
+	args := m.Called(ctx, providerName, providerMessageID)
+	return args.Get(0).(entity.Message), args.Error(1)
+}
+
+func (m *MockMessageRepo) RenewClaim(ctx context.Context, id uint, workerID string) error {
+	args := m.Called(ctx, id, workerID)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepo) Release(ctx context.Context, id uint, workerID string) error {
+	args := m.Called(ctx, id, workerID)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepo) RecordFailure(
+	ctx context.Context,
+	id uint,
+	workerID string,
+	status string,
+	lastError string,
+	nextAttemptAt time.Time,
+) error {
+	args := m.Called(ctx, id, workerID, status, lastError, nextAttemptAt)
+	return args.Error(0)
+}
+
 func (m *MockMessageRepo) Update(ctx context.Context, id uint, status string) error {
 	args := m.Called(ctx, id, status)
 	return args.Error(0)
 }
 
+func (m *MockMessageRepo) Create(ctx context.Context, message *entity.Message) error {
+	args := m.Called(ctx, message)
+	message.ID = 1
+	return args.Error(0)
+}
+
+func (m *MockMessageRepo) CreateBatch(ctx context.Context, messages []entity.Message) error {
+	args := m.Called(ctx, messages)
+	return args.Error(0)
+}
+
 func setupRedisClient() *redis.Client {
 	return redis.NewClient(&redis.Options{
 		Addr: "localhost:6379",
@@ -132,14 +219,474 @@ func TestProcessHandlesDBErrorGracefully(t *testing.T) {
 	redisClient := setupRedisClient()
 	var mu sync.Mutex
 
-	mockRepo.On("GetByStatus", ctx, entity.StatusPending, messageCountPerMinute).Return([]entity.Message{}, errors.New("DB error"))
+	mockRepo.On("ClaimPending", ctx, defaultBatchSize, mock.Anything).Return([]entity.Message{}, errors.New("DB error"))
 
 	stopChan := make(chan bool, 1)
 	service := NewMessageService(mockRepo, stopChan, redisClient, &mu, false)
 
 	// Test process handling DB error
-	err := service.process(ctx)
+	_, err := service.process(ctx)
 	assert.Error(t, err)
 	assert.Equal(t, "error occurred when getting messages", err.Error())
 	mockRepo.AssertExpectations(t)
 }
+
+func TestCreate(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*entity.Message")).Return(nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	message, err := service.Create(ctx, model.MessageRequest{To: " +905551111111 ", Content: "Hello"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), message.ID)
+	assert.Equal(t, "+905551111111", message.PhoneNumber)
+	assert.Equal(t, entity.StatusPending, message.Status)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateValidation(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	tests := []struct {
+		name  string
+		req   model.MessageRequest
+		field string
+	}{
+		{"missing plus", model.MessageRequest{To: "905551111111", Content: "Hello"}, "to"},
+		{"too long number", model.MessageRequest{To: "+9055511111111111", Content: "Hello"}, "to"},
+		{"empty content", model.MessageRequest{To: "+905551111111", Content: "  "}, "content"},
+		{"content too long", model.MessageRequest{To: "+905551111111", Content: strings.Repeat("a", 161)}, "content"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Create(ctx, tt.req)
+
+			var validationErr *ValidationError
+			assert.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.field, validationErr.Field)
+		})
+	}
+
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestRetrievePaginates(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	createdAt := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	mockRepo.On("List", ctx, model.MessageFilter{Status: entity.StatusSent, Limit: 3}, (*model.Cursor)(nil)).Return([]entity.Message{
+		{ID: 1, CreatedAt: createdAt},
+		{ID: 2, CreatedAt: createdAt.Add(time.Minute)},
+		{ID: 3, CreatedAt: createdAt.Add(2 * time.Minute)},
+	}, nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	messages, nextCursor, err := service.Retrieve(ctx, model.MessageFilter{Status: entity.StatusSent, Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, messages, 2)
+	assert.NotEmpty(t, nextCursor)
+
+	cursor, err := decodeCursor(nextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), cursor.ID)
+	assert.True(t, createdAt.Add(time.Minute).Equal(cursor.CreatedAt))
+
+	mockRepo.On("List", ctx, model.MessageFilter{Status: entity.StatusSent, Limit: 3, Cursor: nextCursor}, cursor).Return([]entity.Message{
+		{ID: 3, CreatedAt: createdAt.Add(2 * time.Minute)},
+	}, nil)
+
+	messages, nextCursor, err = service.Retrieve(ctx, model.MessageFilter{Status: entity.StatusSent, Limit: 2, Cursor: nextCursor})
+	assert.NoError(t, err)
+	assert.Len(t, messages, 1)
+	assert.Empty(t, nextCursor)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRetrieveRejectsInvalidInput(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	var mu sync.Mutex
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	var validationErr *ValidationError
+
+	_, _, err := service.Retrieve(context.Background(), model.MessageFilter{Cursor: "not-a-cursor"})
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "cursor", validationErr.Field)
+
+	_, _, err = service.Retrieve(context.Background(), model.MessageFilter{Limit: maxPageSize + 1})
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "limit", validationErr.Field)
+}
+
+func TestGetWithoutProviderMessageID(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	sentAt := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	mockRepo.On("GetByID", ctx, uint(1)).Return(entity.Message{
+		ID:           1,
+		Status:       entity.StatusSent,
+		SentAt:       sentAt,
+		ProviderName: "smtp",
+	}, nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	detail, err := service.Get(ctx, 1)
+	assert.NoError(t, err)
+	assert.Empty(t, detail.ProviderMessageID)
+	assert.Equal(t, "2025-01-01T10:00:00Z", detail.SendingTime)
+}
+
+func TestGetNotFound(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	mockRepo.On("GetByID", ctx, uint(42)).Return(entity.Message{}, gorm.ErrRecordNotFound)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	_, err := service.Get(ctx, 42)
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestReaperReleasesExpiredClaims(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	released := make(chan time.Time, 1)
+	mockRepo.On("ReleaseExpiredClaims", mock.Anything, mock.AnythingOfType("time.Time"), 3).
+		Run(func(args mock.Arguments) {
+			select {
+			case released <- args.Get(1).(time.Time):
+			default:
+			}
+		}).
+		Return(int64(1), nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false,
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3}))
+	// shorter than WithVisibilityTimeout allows, to keep the test fast
+	service.visibilityTimeout = 100 * time.Millisecond
+
+	service.StartProcess(ctx)
+	defer service.StopProcess()
+
+	select {
+	case claimedBefore := <-released:
+		assert.WithinDuration(t, time.Now().Add(-100*time.Millisecond), claimedBefore, 100*time.Millisecond)
+	case <-time.After(time.Second):
+		t.Fatal("expired claims were not released")
+	}
+}
+
+func TestWithVisibilityTimeoutKeepsDefaultBelowMinimum(t *testing.T) {
+	service := NewMessageService(new(MockMessageRepo), make(chan bool, 1), setupRedisClient(), nil, false,
+		WithVisibilityTimeout(time.Nanosecond))
+	assert.Equal(t, defaultVisibilityTimeout, service.visibilityTimeout)
+
+	service = NewMessageService(new(MockMessageRepo), make(chan bool, 1), setupRedisClient(), nil, false,
+		WithVisibilityTimeout(time.Minute))
+	assert.Equal(t, time.Minute, service.visibilityTimeout)
+}
+
+func TestSetRateReconfiguresRunningProcess(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	claimed := make(chan int, 1)
+	mockRepo.On("RenewClaim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("ClaimPending", mock.Anything, 5, mock.Anything).
+		Run(func(args mock.Arguments) {
+			select {
+			case claimed <- args.Int(1):
+			default:
+			}
+		}).
+		Return([]entity.Message{}, nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	service.StartProcess(ctx)
+	defer service.StopProcess()
+
+	err := service.SetRate(ctx, time.Second, 5)
+	assert.NoError(t, err)
+
+	select {
+	case batchSize := <-claimed:
+		assert.Equal(t, 5, batchSize)
+	case <-time.After(3 * time.Second):
+		t.Fatal("process did not run with the new rate")
+	}
+}
+
+func TestSetRateValidation(t *testing.T) {
+	var mu sync.Mutex
+	service := NewMessageService(new(MockMessageRepo), make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	var validationErr *ValidationError
+
+	err := service.SetRate(context.Background(), 10*time.Millisecond, 5)
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "interval", validationErr.Field)
+
+	err = service.SetRate(context.Background(), time.Minute, 0)
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "batch_size", validationErr.Field)
+
+	interval, batchSize := service.Rate()
+	assert.Equal(t, defaultProcessInterval, interval)
+	assert.Equal(t, defaultBatchSize, batchSize)
+}
+
+func TestProcessNowReturnsResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"messageId":"67f2f8a8-ea58-4ed0-a6f9-ff217df4d849","message":"Accepted"}`))
+	}))
+	defer server.Close()
+
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	mockRepo.On("RenewClaim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("ClaimPending", ctx, defaultBatchSize, mock.Anything).Return([]entity.Message{
+		{ID: 1, PhoneNumber: "+905551111111", Content: "Hello", Status: entity.StatusProcessing},
+	}, nil)
+	mockRepo.On("MarkSent", ctx, uint(1), mock.Anything, sender.TypeWebhook, model.Response{
+		MessageID: "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849",
+		Message:   "Accepted",
+		Raw:       `{"messageId":"67f2f8a8-ea58-4ed0-a6f9-ff217df4d849","message":"Accepted"}`,
+	}).Return(nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false,
+		WithSender(sender.NewWebhookSender(sender.WebhookConfig{URL: server.URL}, nil)))
+
+	results, err := service.ProcessNow(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.ProcessResult{
+		{ID: 1, Status: entity.StatusSent, Provider: sender.TypeWebhook, ProviderMessageID: "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849"},
+	}, results)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProcessSkipsMessagesWhoseClaimWasLost(t *testing.T) {
+	var sent atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		sent.Store(true)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	mockRepo.On("ClaimPending", ctx, defaultBatchSize, mock.Anything).Return([]entity.Message{
+		{ID: 1, PhoneNumber: "+905551111111", Content: "Hello", Status: entity.StatusProcessing},
+	}, nil)
+	mockRepo.On("RenewClaim", ctx, uint(1), mock.Anything).Return(repository.ErrClaimLost)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false,
+		WithSender(sender.NewWebhookSender(sender.WebhookConfig{URL: server.URL}, nil)))
+
+	results, err := service.ProcessNow(ctx)
+	assert.NoError(t, err)
+	assert.False(t, sent.Load())
+	assert.Equal(t, []model.ProcessResult{
+		{ID: 1, Status: entity.StatusProcessing, Error: repository.ErrClaimLost.Error(), Released: true},
+	}, results)
+	mockRepo.AssertNotCalled(t, "Release", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProcessReleasesSendsThatOutlastTheClaim(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-unblock
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	mockRepo.On("RenewClaim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("ClaimPending", ctx, defaultBatchSize, mock.Anything).Return([]entity.Message{
+		{ID: 1, PhoneNumber: "+905551111111", Content: "Hello", Status: entity.StatusProcessing},
+	}, nil)
+	mockRepo.On("Release", ctx, uint(1), mock.Anything).Return(nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false,
+		WithSender(sender.NewWebhookSender(sender.WebhookConfig{URL: server.URL}, nil)))
+	service.visibilityTimeout = 200 * time.Millisecond
+
+	results, err := service.ProcessNow(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, entity.StatusPending, results[0].Status)
+	assert.True(t, results[0].Released)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestStartProcessRunsOnStart(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	claimed := make(chan struct{}, 1)
+	mockRepo.On("RenewClaim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("ClaimPending", mock.Anything, defaultBatchSize, mock.Anything).
+		Run(func(mock.Arguments) {
+			select {
+			case claimed <- struct{}{}:
+			default:
+			}
+		}).
+		Return([]entity.Message{}, nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false, WithRunOnStart(true))
+
+	service.StartProcess(ctx)
+	defer service.StopProcess()
+
+	select {
+	case <-claimed:
+	case <-time.After(time.Second):
+		t.Fatal("process did not run on start")
+	}
+}
+
+func TestStopProcessCancelsInFlightSend(t *testing.T) {
+	requested := make(chan struct{}, 1)
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested <- struct{}{}
+		<-unblock
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	released := make(chan uint, 1)
+	mockRepo.On("RenewClaim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("ClaimPending", mock.Anything, defaultBatchSize, mock.Anything).Return([]entity.Message{
+		{ID: 1, PhoneNumber: "+905551111111", Content: "Hello", Status: entity.StatusProcessing},
+	}, nil).Once()
+	mockRepo.On("Release", mock.Anything, uint(1), mock.Anything).
+		Run(func(args mock.Arguments) {
+			assert.NoError(t, args.Get(0).(context.Context).Err())
+			released <- args.Get(1).(uint)
+		}).
+		Return(nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false,
+		WithSender(sender.NewWebhookSender(sender.WebhookConfig{URL: server.URL}, nil)),
+		WithRunOnStart(true))
+
+	service.StartProcess(ctx)
+
+	select {
+	case <-requested:
+	case <-time.After(time.Second):
+		t.Fatal("message was not sent")
+	}
+
+	service.StopProcess()
+
+	select {
+	case id := <-released:
+		assert.Equal(t, uint(1), id)
+	case <-time.After(time.Second):
+		t.Fatal("in-flight send was not canceled")
+	}
+}
+
+func TestWaitReturnsOnceShutdownReleasedMessages(t *testing.T) {
+	requested := make(chan struct{}, 1)
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested <- struct{}{}
+		<-unblock
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	mockRepo := new(MockMessageRepo)
+	ctx, cancel := context.WithCancel(context.Background())
+	var mu sync.Mutex
+
+	mockRepo.On("RenewClaim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("ClaimPending", mock.Anything, defaultBatchSize, mock.Anything).Return([]entity.Message{
+		{ID: 1, PhoneNumber: "+905551111111", Content: "Hello", Status: entity.StatusProcessing},
+	}, nil).Once()
+	mockRepo.On("Release", mock.Anything, uint(1), mock.Anything).Return(nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false,
+		WithSender(sender.NewWebhookSender(sender.WebhookConfig{URL: server.URL}, nil)),
+		WithRunOnStart(true))
+
+	service.StartProcess(ctx)
+
+	select {
+	case <-requested:
+	case <-time.After(time.Second):
+		t.Fatal("message was not sent")
+	}
+
+	cancel()
+	service.Wait()
+
+	assert.False(t, service.Status().Running)
+	mockRepo.AssertCalled(t, "Release", mock.Anything, uint(1), mock.Anything)
+}
+
+func TestGetByProviderMessageID(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	sentAt := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	mockRepo.On("GetByProviderMessageID", ctx, "webhook", "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849").Return(entity.Message{
+		ID:                1,
+		Status:            entity.StatusSent,
+		SentAt:            sentAt,
+		ProviderName:      "webhook",
+		ProviderMessageID: "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849",
+	}, nil)
+	mockRepo.On("GetByProviderMessageID", ctx, "webhook", "unknown").Return(entity.Message{}, gorm.ErrRecordNotFound)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	detail, err := service.GetByProviderMessageID(ctx, "webhook", "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), detail.ID)
+	assert.Equal(t, "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849", detail.ProviderMessageID)
+	assert.Equal(t, "2025-01-01T10:00:00Z", detail.SendingTime)
+
+	_, err = service.GetByProviderMessageID(ctx, "webhook", "unknown")
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"github.com/busragumusel/insider-case/internal/repository"
+	"github.com/busragumusel/insider-case/internal/sender"
+	"net/http"
+	"time"
+)
+
+type Option func(*MessageService)
+
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(s *MessageService) {
+		s.retryPolicy = policy
+	}
+}
+
+func WithWorkerID(workerID string) Option {
+	return func(s *MessageService) {
+		s.workerID = workerID
+	}
+}
+
+// WithVisibilityTimeout sets how long a claimed message may stay in processing
+// before it is considered abandoned and released. Timeouts shorter than 10
+// seconds keep the default.
+func WithVisibilityTimeout(timeout time.Duration) Option {
+	return func(s *MessageService) {
+		if timeout >= minVisibilityTimeout {
+			s.visibilityTimeout = timeout
+		}
+	}
+}
+
+// WithRate sets the initial processing interval and batch size. Invalid values
+// keep the defaults.
+func WithRate(interval time.Duration, batchSize int) Option {
+	return func(s *MessageService) {
+		if interval >= minProcessInterval {
+			s.interval = interval
+		}
+		if batchSize > 0 && batchSize <= maxBatchSize {
+			s.batchSize = batchSize
+		}
+	}
+}
+
+// WithRunOnStart makes StartProcess send a batch immediately instead of
+// waiting for the first tick.
+func WithRunOnStart(runOnStart bool) Option {
+	return func(s *MessageService) {
+		s.runOnStart = runOnStart
+	}
+}
+
+// WithConcurrency sets how many messages of a batch are sent at the same
+// time. Values outside 1 to 100 keep the default of 1.
+func WithConcurrency(concurrency int) Option {
+	return func(s *MessageService) {
+		if concurrency > 0 && concurrency <= maxConcurrency {
+			s.concurrency = concurrency
+		}
+	}
+}
+
+// WithLeadership makes the processing loop run only while this instance is
+// the leader.
+func WithLeadership(leadership Leadership) Option {
+	return func(s *MessageService) {
+		s.leadership = leadership
+	}
+}
+
+// WithSender sends every message through a single provider.
+func WithSender(messageSender sender.Sender) Option {
+	return func(s *MessageService) {
+		s.router = sender.SingleRouter(messageSender)
+	}
+}
+
+// WithHTTPClient sets the client the default webhook provider sends with. It
+// has no effect on providers given with WithSender or WithRouter.
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *MessageService) {
+		s.httpClient = client
+	}
+}
+
+// WithRouter sends messages through the providers the router picks for them.
+func WithRouter(router *sender.Router) Option {
+	return func(s *MessageService) {
+		s.router = router
+	}
+}
+
+// WithSettingRepo stores the processing state requested with RequestStart and
+// RequestStop, so that it outlasts restarts of every instance.
+func WithSettingRepo(settings repository.SettingRepo) Option {
+	return func(s *MessageService) {
+		s.settings = settings
+	}
+}
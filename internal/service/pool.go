@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"github.com/busragumusel/insider-case/internal/entity"
+	"github.com/busragumusel/insider-case/internal/model"
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	defaultConcurrency = 1
+	maxConcurrency     = 100
+)
+
+// sendBatch sends the claimed messages with up to s.concurrency requests in
+// flight. The batch size still bounds how many messages a run sends, so the
+// rate doesn't change, only how long a run takes. Results are in the order of
+// messages. It reports whether a provider rate limited the batch.
+func (s *MessageService) sendBatch(ctx context.Context, messages []entity.Message) ([]model.ProcessResult, bool) {
+	results := make([]model.ProcessResult, len(messages))
+	var rateLimited atomic.Bool
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(s.concurrency, len(messages)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = s.sendMessage(ctx, messages[i], &rateLimited)
+			}
+		}()
+	}
+
+	for i := range messages {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	sent := 0
+	for _, result := range results {
+		if result.Status == entity.StatusSent {
+			sent++
+		}
+	}
+	log.Printf("Sent %d of %d messages.", sent, len(messages))
+
+	return results, rateLimited.Load()
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/busragumusel/insider-case/internal/entity"
+	"github.com/busragumusel/insider-case/internal/sender"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestProcessSendsBatchConcurrently(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			seen := maxInFlight.Load()
+			if current <= seen || maxInFlight.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"messageId":"67f2f8a8-ea58-4ed0-a6f9-ff217df4d849","message":"Accepted"}`))
+	}))
+	defer server.Close()
+
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	messages := make([]entity.Message, 6)
+	for i := range messages {
+		messages[i] = entity.Message{ID: uint(i + 1), PhoneNumber: "+905551111111", Content: "Hello", Status: entity.StatusProcessing}
+	}
+	mockRepo.On("RenewClaim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("ClaimPending", ctx, 6, mock.Anything).Return(messages, nil)
+	mockRepo.On("MarkSent", ctx, mock.Anything, mock.Anything, sender.TypeWebhook, mock.Anything).Return(nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false,
+		WithSender(sender.NewWebhookSender(sender.WebhookConfig{URL: server.URL}, nil)),
+		WithRate(time.Minute, 6),
+		WithConcurrency(3))
+
+	start := time.Now()
+	results, err := service.ProcessNow(ctx)
+	elapsed := time.Since(start)
+
+	assert.NoError(t, err)
+	assert.Len(t, results, 6)
+	for i, result := range results {
+		assert.Equal(t, uint(i+1), result.ID)
+		assert.Equal(t, entity.StatusSent, result.Status)
+	}
+	assert.Equal(t, int32(3), maxInFlight.Load())
+	assert.Less(t, elapsed, 500*time.Millisecond)
+	mockRepo.AssertNumberOfCalls(t, "MarkSent", 6)
+}
+
+func TestWithConcurrencyKeepsDefaultForInvalidValues(t *testing.T) {
+	var mu sync.Mutex
+
+	for _, concurrency := range []int{0, -1, maxConcurrency + 1} {
+		service := NewMessageService(new(MockMessageRepo), make(chan bool, 1), setupRedisClient(), &mu, false,
+			WithConcurrency(concurrency))
+
+		assert.Equal(t, defaultConcurrency, service.concurrency, concurrency)
+	}
+}
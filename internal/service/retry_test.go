@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/busragumusel/insider-case/internal/entity"
+	"github.com/busragumusel/insider-case/internal/sender"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: 10 * time.Second, MaxDelay: time.Minute}
+
+	tests := []struct {
+		attempts int
+		max      time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{4, time.Minute},
+		{10, time.Minute},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			delay := policy.Backoff(tt.attempts)
+			assert.GreaterOrEqual(t, delay, tt.max/2)
+			assert.LessOrEqual(t, delay, tt.max)
+		}
+	}
+}
+
+func newWebhookServer(t *testing.T, status int) Option {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return WithSender(sender.NewWebhookSender(sender.WebhookConfig{URL: server.URL}, nil))
+}
+
+func TestProcessSchedulesRetryOnTransientFailure(t *testing.T) {
+	webhook := newWebhookServer(t, http.StatusServiceUnavailable)
+
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	mockRepo.On("RenewClaim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("ClaimPending", ctx, defaultBatchSize, mock.Anything).Return([]entity.Message{
+		{ID: 1, PhoneNumber: "+905551111111", Content: "Hello", Status: entity.StatusPending, AttemptCount: 1},
+	}, nil)
+	mockRepo.On("RecordFailure", ctx, uint(1), mock.Anything, entity.StatusPending, mock.Anything, mock.MatchedBy(func(next time.Time) bool {
+		return next.After(time.Now().Add(9*time.Second)) && next.Before(time.Now().Add(21*time.Second))
+	})).Return(nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false, webhook,
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Second, MaxDelay: time.Minute}))
+
+	_, err := service.process(ctx)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProcessFailsAfterMaxAttempts(t *testing.T) {
+	webhook := newWebhookServer(t, http.StatusServiceUnavailable)
+
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	mockRepo.On("RenewClaim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("ClaimPending", ctx, defaultBatchSize, mock.Anything).Return([]entity.Message{
+		{ID: 1, PhoneNumber: "+905551111111", Content: "Hello", Status: entity.StatusPending, AttemptCount: 2},
+	}, nil)
+	mockRepo.On("RecordFailure", ctx, uint(1), mock.Anything, entity.StatusFailed, mock.Anything, time.Time{}).Return(nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false, webhook,
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Second, MaxDelay: time.Minute}))
+
+	_, err := service.process(ctx)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProcessFailsOnPermanentError(t *testing.T) {
+	webhook := newWebhookServer(t, http.StatusBadRequest)
+
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	mockRepo.On("RenewClaim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("ClaimPending", ctx, defaultBatchSize, mock.Anything).Return([]entity.Message{
+		{ID: 1, PhoneNumber: "+905551111111", Content: "Hello", Status: entity.StatusPending},
+	}, nil)
+	mockRepo.On("RecordFailure", ctx, uint(1), mock.Anything, entity.StatusFailed, "failed to send request: 400 Bad Request", time.Time{}).Return(nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false, webhook)
+
+	_, err := service.process(ctx)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"github.com/busragumusel/insider-case/internal/entity"
+	"github.com/busragumusel/insider-case/internal/model"
+	"time"
+)
+
+const (
+	runResultOK    = "ok"
+	runResultError = "error"
+)
+
+// processStats describes what the processing loop has been doing. It is
+// guarded by MessageService.mu.
+type processStats struct {
+	startedAt     time.Time
+	lastRunAt     time.Time
+	lastRunResult string
+	lastRunError  string
+	nextRunAt     time.Time
+	lastRunSent   int
+	lastRunFailed int
+	totalSent     int
+	totalFailed   int
+}
+
+func (s *MessageService) Status() model.ProcessStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	leaderID := s.workerID
+	if s.leadership != nil {
+		leaderID = s.leadership.LeaderID()
+	}
+
+	var throttledUntil *time.Time
+	if time.Now().Before(s.throttle.pausedUntil) {
+		throttledUntil = timePtr(s.throttle.pausedUntil)
+	}
+
+	return model.ProcessStatus{
+		Running:       s.running,
+		WorkerID:      s.workerID,
+		Leader:        s.leading(),
+		LeaderID:      leaderID,
+		StartedAt:     timePtr(s.stats.startedAt),
+		LastRunAt:     timePtr(s.stats.lastRunAt),
+		LastRunResult: s.stats.lastRunResult,
+		LastRunError:  s.stats.lastRunError,
+		NextRunAt:     timePtr(s.stats.nextRunAt),
+		LastRunSent:   s.stats.lastRunSent,
+		LastRunFailed: s.stats.lastRunFailed,
+		TotalSent:     s.stats.totalSent,
+		TotalFailed:   s.stats.totalFailed,
+
+		Interval:           s.interval.String(),
+		BatchSize:          s.batchSize,
+		EffectiveBatchSize: s.effectiveBatchSize(),
+		ThrottledUntil:     throttledUntil,
+
+		DesiredState: s.desired,
+
+		Providers: s.router.Status(),
+	}
+}
+
+func (s *MessageService) recordStop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.nextRunAt = time.Time{}
+}
+
+func (s *MessageService) recordNextRun(nextRunAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.nextRunAt = nextRunAt
+}
+
+func (s *MessageService) recordRun(results []model.ProcessResult, err error) {
+	sent, failed := 0, 0
+	for _, result := range results {
+		switch {
+		case result.Status == entity.StatusSent:
+			sent++
+		case !result.Released:
+			failed++
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.lastRunAt = time.Now()
+	s.stats.lastRunSent = sent
+	s.stats.lastRunFailed = failed
+	s.stats.totalSent += sent
+	s.stats.totalFailed += failed
+
+	if err != nil {
+		s.stats.lastRunResult = runResultError
+		s.stats.lastRunError = err.Error()
+		return
+	}
+
+	s.stats.lastRunResult = runResultOK
+	s.stats.lastRunError = ""
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
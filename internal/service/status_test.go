@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/busragumusel/insider-case/internal/entity"
+	"github.com/busragumusel/insider-case/internal/sender"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStatusTracksRuns(t *testing.T) {
+	webhook := newWebhookServer(t, http.StatusServiceUnavailable)
+
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	mockRepo.On("RenewClaim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("ClaimPending", ctx, defaultBatchSize, mock.Anything).Return([]entity.Message{
+		{ID: 1, PhoneNumber: "+905551111111", Content: "Hello", Status: entity.StatusProcessing},
+	}, nil).Once()
+	mockRepo.On("ClaimPending", ctx, defaultBatchSize, mock.Anything).Return([]entity.Message{}, errors.New("DB error")).Once()
+	mockRepo.On("RecordFailure", ctx, uint(1), mock.Anything, entity.StatusPending, mock.Anything, mock.Anything).Return(nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false, webhook, WithWorkerID("worker-1"))
+
+	status := service.Status()
+	assert.False(t, status.Running)
+	assert.Nil(t, status.LastRunAt)
+
+	_, err := service.ProcessNow(ctx)
+	assert.NoError(t, err)
+
+	status = service.Status()
+	assert.Equal(t, "worker-1", status.WorkerID)
+	assert.Equal(t, runResultOK, status.LastRunResult)
+	assert.Equal(t, 0, status.LastRunSent)
+	assert.Equal(t, 1, status.LastRunFailed)
+	assert.Equal(t, 1, status.TotalFailed)
+	assert.NotNil(t, status.LastRunAt)
+
+	_, err = service.ProcessNow(ctx)
+	assert.Error(t, err)
+
+	status = service.Status()
+	assert.Equal(t, runResultError, status.LastRunResult)
+	assert.Equal(t, "error occurred when getting messages", status.LastRunError)
+	assert.Equal(t, 0, status.LastRunFailed)
+	assert.Equal(t, 1, status.TotalFailed)
+}
+
+func TestStatusWhileRunning(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false)
+
+	service.StartProcess(ctx)
+	time.Sleep(50 * time.Millisecond)
+
+	status := service.Status()
+	assert.True(t, status.Running)
+	assert.NotNil(t, status.StartedAt)
+	assert.WithinDuration(t, time.Now().Add(defaultProcessInterval), *status.NextRunAt, time.Second)
+
+	service.StopProcess()
+	time.Sleep(50 * time.Millisecond)
+
+	status = service.Status()
+	assert.False(t, status.Running)
+	assert.Nil(t, status.NextRunAt)
+}
+
+func TestProcessPausesWhileCircuitIsOpen(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	breaker := sender.NewCircuitBreaker(
+		sender.NewWebhookSender(sender.WebhookConfig{URL: "http://127.0.0.1:1"}, nil),
+		sender.BreakerConfig{FailureThreshold: 1, CoolDown: sender.Duration(time.Minute)},
+	)
+
+	mockRepo.On("RenewClaim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("ClaimPending", ctx, defaultBatchSize, mock.Anything).Return([]entity.Message{
+		{ID: 1, PhoneNumber: "+905551111111", Content: "Hello", Status: entity.StatusProcessing},
+		{ID: 2, PhoneNumber: "+905552222222", Content: "Hello", Status: entity.StatusProcessing},
+	}, nil).Once()
+	mockRepo.On("RecordFailure", ctx, uint(1), mock.Anything, entity.StatusPending, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("Release", ctx, uint(2), mock.Anything).Return(nil)
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false, WithSender(breaker))
+
+	results, err := service.ProcessNow(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.StatusPending, results[1].Status)
+	assert.Equal(t, sender.ErrCircuitOpen.Error(), results[1].Error)
+	assert.True(t, results[1].Released)
+	assert.Equal(t, 1, service.Status().LastRunFailed)
+
+	_, err = service.ProcessNow(ctx)
+	assert.ErrorIs(t, err, ErrProvidersUnavailable)
+
+	status := service.Status()
+	assert.Equal(t, sender.BreakerOpen, status.Providers[0].CircuitState)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"log"
+	"time"
+)
+
+const (
+	// defaultRetryAfter is how long sending pauses when a provider rate
+	// limits us without saying for how long.
+	defaultRetryAfter = 30 * time.Second
+	// throttleRecoverySteps is how many runs without pushback it takes to
+	// get back from a batch size of 1 to the configured one.
+	throttleRecoverySteps = 10
+)
+
+// throttle slows sending down while providers rate limit us: every rate limit
+// pauses sending and halves the batch size, and every run that isn't rate
+// limited grows it back towards the configured size. It is guarded by
+// MessageService.mu.
+type throttle struct {
+	pausedUntil time.Time
+	// batchSize is the reduced batch size, zero when not throttled.
+	batchSize int
+}
+
+// throttledRate returns the batch size to claim and until when sending is
+// paused.
+func (s *MessageService) throttledRate() (int, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.effectiveBatchSize(), s.throttle.pausedUntil
+}
+
+// effectiveBatchSize must be called with s.mu held.
+func (s *MessageService) effectiveBatchSize() int {
+	if s.throttle.batchSize > 0 && s.throttle.batchSize < s.batchSize {
+		return s.throttle.batchSize
+	}
+
+	return s.batchSize
+}
+
+func (s *MessageService) recordRateLimit(retryAfter time.Duration) {
+	if retryAfter <= 0 {
+		retryAfter = defaultRetryAfter
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.throttle.pausedUntil = time.Now().Add(retryAfter)
+	s.throttle.batchSize = max(1, s.effectiveBatchSize()/2)
+
+	log.Printf("Rate limited by provider, pausing for %s and sending %d messages per run.",
+		retryAfter, s.throttle.batchSize)
+}
+
+func (s *MessageService) recordNoRateLimit() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.throttle.batchSize == 0 {
+		return
+	}
+
+	s.throttle.batchSize += max(1, s.batchSize/throttleRecoverySteps)
+	if s.throttle.batchSize >= s.batchSize {
+		s.throttle.batchSize = 0
+		log.Printf("No longer throttled, sending %d messages per run.", s.batchSize)
+	}
+}
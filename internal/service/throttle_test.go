@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/busragumusel/insider-case/internal/entity"
+	"github.com/busragumusel/insider-case/internal/sender"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestProcessThrottlesOnRateLimit(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusTooManyRequests)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if status.Load() == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"messageId":"provider-1","message":"Accepted"}`))
+	}))
+	defer server.Close()
+
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	mockRepo.On("RenewClaim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("ClaimPending", ctx, 8, mock.Anything).Return([]entity.Message{
+		{ID: 1, PhoneNumber: "+905551111111", Content: "Hello", Status: entity.StatusProcessing},
+		{ID: 2, PhoneNumber: "+905551111112", Content: "Hello", Status: entity.StatusProcessing},
+	}, nil).Once()
+	mockRepo.On("Release", ctx, uint(1), mock.Anything).Return(nil).Once()
+	mockRepo.On("Release", ctx, uint(2), mock.Anything).Return(nil).Once()
+
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false,
+		WithSender(sender.NewWebhookSender(sender.WebhookConfig{URL: server.URL}, nil)),
+		WithRate(time.Minute, 8),
+	)
+
+	results, err := service.ProcessNow(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, entity.StatusPending, results[1].Status)
+	assert.Equal(t, ErrRateLimited.Error(), results[1].Error)
+
+	processStatus := service.Status()
+	assert.Equal(t, 8, processStatus.BatchSize)
+	assert.Equal(t, 4, processStatus.EffectiveBatchSize)
+	if assert.NotNil(t, processStatus.ThrottledUntil) {
+		assert.WithinDuration(t, time.Now().Add(time.Minute), *processStatus.ThrottledUntil, 2*time.Second)
+	}
+
+	// paused: nothing is claimed
+	_, err = service.ProcessNow(ctx)
+	assert.ErrorIs(t, err, ErrRateLimited)
+
+	// once the pause is over the smaller batch is sent and grows back
+	mu.Lock()
+	service.throttle.pausedUntil = time.Now().Add(-time.Second)
+	mu.Unlock()
+	status.Store(http.StatusAccepted)
+
+	mockRepo.On("ClaimPending", ctx, 4, mock.Anything).Return([]entity.Message{
+		{ID: 1, PhoneNumber: "+905551111111", Content: "Hello", Status: entity.StatusProcessing},
+	}, nil).Once()
+	mockRepo.On("MarkSent", ctx, uint(1), mock.Anything, sender.TypeWebhook, mock.Anything).Return(nil).Once()
+
+	_, err = service.ProcessNow(ctx)
+	assert.NoError(t, err)
+
+	processStatus = service.Status()
+	assert.Equal(t, 5, processStatus.EffectiveBatchSize)
+	assert.Nil(t, processStatus.ThrottledUntil)
+	mockRepo.AssertExpectations(t)
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Wait(_ context.Context) error {
+	return errors.New("failed to take rate limit token: connection refused")
+}
+
+func TestProcessReleasesWhenLimiterFails(t *testing.T) {
+	mockRepo := new(MockMessageRepo)
+	ctx := context.Background()
+	var mu sync.Mutex
+
+	mockRepo.On("RenewClaim", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("ClaimPending", ctx, defaultBatchSize, mock.Anything).Return([]entity.Message{
+		{ID: 1, PhoneNumber: "+905551111111", Content: "Hello", Status: entity.StatusProcessing},
+	}, nil).Once()
+	mockRepo.On("Release", ctx, uint(1), mock.Anything).Return(nil).Once()
+
+	limited := sender.NewLimitedSender(sender.NewWebhookSender(sender.WebhookConfig{URL: "http://127.0.0.1:1"}, nil), failingLimiter{})
+	service := NewMessageService(mockRepo, make(chan bool, 1), setupRedisClient(), &mu, false, WithSender(limited))
+
+	results, err := service.ProcessNow(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.StatusPending, results[0].Status)
+	assert.True(t, results[0].Released)
+	assert.Equal(t, 0, service.Status().LastRunFailed)
+	mockRepo.AssertNotCalled(t, "RecordFailure", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"fmt"
+	"github.com/busragumusel/insider-case/internal/model"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxContentLength = 160
+
+var e164Regexp = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// normalizeMessage cleans up a message request the same way for every way a
+// message can be created, before it is validated.
+func normalizeMessage(req model.MessageRequest) model.MessageRequest {
+	req.To = strings.TrimSpace(req.To)
+
+	return req
+}
+
+func validateMessage(req model.MessageRequest) error {
+	if !e164Regexp.MatchString(req.To) {
+		return &ValidationError{Field: "to", Message: "phone number must be in E.164 format"}
+	}
+
+	if strings.TrimSpace(req.Content) == "" {
+		return &ValidationError{Field: "content", Message: "content must not be empty"}
+	}
+
+	if utf8.RuneCountInString(req.Content) > maxContentLength {
+		return &ValidationError{
+			Field:   "content",
+			Message: fmt.Sprintf("content must be at most %d characters", maxContentLength),
+		}
+	}
+
+	return nil
+}
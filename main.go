@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	_ "github.com/busragumusel/insider-case/docs" // Import Swagger Docs
 	"github.com/busragumusel/insider-case/internal/api"
 	"github.com/busragumusel/insider-case/internal/entity"
+	"github.com/busragumusel/insider-case/internal/leader"
 	"github.com/busragumusel/insider-case/internal/repository"
+	"github.com/busragumusel/insider-case/internal/sender"
 	"github.com/busragumusel/insider-case/internal/service"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -18,9 +21,17 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
 	"sync"
+	"syscall"
+	"time"
 )
 
+// shutdownTimeout bounds how long requests in flight may take to finish on
+// shutdown.
+const shutdownTimeout = 10 * time.Second
+
 var db *gorm.DB
 
 func initDB() {
@@ -38,7 +49,7 @@ func initDB() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
-	err = db.AutoMigrate(&entity.Message{})
+	err = db.AutoMigrate(&entity.Message{}, &entity.Setting{})
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
@@ -74,9 +85,27 @@ func initRedis() *redis.Client {
 	return client
 }
 
+func retryPolicyFromEnv() service.RetryPolicy {
+	policy := service.DefaultRetryPolicy()
+
+	if value, err := strconv.Atoi(os.Getenv("RETRY_MAX_ATTEMPTS")); err == nil && value > 0 {
+		policy.MaxAttempts = value
+	}
+	if value, err := time.ParseDuration(os.Getenv("RETRY_BASE_DELAY")); err == nil && value > 0 {
+		policy.BaseDelay = value
+	}
+	if value, err := time.ParseDuration(os.Getenv("RETRY_MAX_DELAY")); err == nil && value > 0 {
+		policy.MaxDelay = value
+	}
+
+	return policy
+}
+
 func main() {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// a shutdown cancels ctx, which aborts the processing run and the provider
+	// requests in flight
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	err := godotenv.Load()
 	if err != nil {
@@ -94,15 +123,91 @@ func main() {
 	messageRepo := repository.NewMessageRepository(db)
 	stopChan := make(chan bool, 1)
 	var mu *sync.Mutex
-	messageService := service.NewMessageService(messageRepo, stopChan, redisClient, mu, false)
-	go messageService.StartProcess(ctx)
+	providerConfig := sender.ConfigFromEnv()
+	if path := os.Getenv("PROVIDERS_CONFIG"); path != "" {
+		providerConfig, err = sender.LoadConfig(path)
+		if err != nil {
+			log.Fatal("Failed to load provider config:", err)
+		}
+	}
+	httpClient, err := sender.NewHTTPClient(sender.HTTPClientConfigFromEnv())
+	if err != nil {
+		log.Fatal("Failed to configure HTTP client:", err)
+	}
+	providerRouter, err := sender.NewRouterFromConfig(providerConfig, httpClient, redisClient)
+	if err != nil {
+		log.Fatal("Failed to configure providers:", err)
+	}
 
-	messageRouter := api.NewAPI(db, redisClient, messageService)
-	messageRouter.RegisterRoutes(router)
+	opts := []service.Option{
+		service.WithRetryPolicy(retryPolicyFromEnv()),
+		service.WithRouter(providerRouter),
+		service.WithSettingRepo(repository.NewSettingRepository(db)),
+	}
+	workerID := os.Getenv("WORKER_ID")
+	if workerID == "" {
+		workerID = service.DefaultWorkerID()
+	}
+	opts = append(opts, service.WithWorkerID(workerID))
+	if timeout, err := time.ParseDuration(os.Getenv("VISIBILITY_TIMEOUT")); err == nil {
+		opts = append(opts, service.WithVisibilityTimeout(timeout))
+	}
+	interval, _ := time.ParseDuration(os.Getenv("PROCESS_INTERVAL"))
+	batchSize, _ := strconv.Atoi(os.Getenv("BATCH_SIZE"))
+	opts = append(opts, service.WithRate(interval, batchSize))
+	if concurrency, err := strconv.Atoi(os.Getenv("SEND_CONCURRENCY")); err == nil {
+		opts = append(opts, service.WithConcurrency(concurrency))
+	}
+	if runOnStart, err := strconv.ParseBool(os.Getenv("PROCESS_ON_START")); err == nil {
+		opts = append(opts, service.WithRunOnStart(runOnStart))
+	}
+	// closed once the elector gave the lock up on shutdown
+	var resigned chan struct{}
+	if leaderElection, err := strconv.ParseBool(os.Getenv("LEADER_ELECTION")); err != nil || leaderElection {
+		lockTTL, _ := time.ParseDuration(os.Getenv("LEADER_LOCK_TTL"))
+		elector := leader.NewElector(redisClient, "scheduler_leader", workerID, lockTTL)
+		// settle leadership before processing starts, or PROCESS_ON_START would
+		// find no leader
+		elector.Campaign(ctx)
+		resigned = make(chan struct{})
+		go func() {
+			elector.Run(ctx)
+			close(resigned)
+		}()
+		opts = append(opts, service.WithLeadership(elector))
+	}
+	messageService := service.NewMessageService(messageRepo, stopChan, redisClient, mu, false, opts...)
+	go messageService.FollowDesiredState(ctx)
 
-	err = http.ListenAndServe(":8080", router)
-	if err != nil {
-		return
+	callbackAuth := api.SignatureConfigFromEnv()
+	if len(callbackAuth.Secrets) == 0 {
+		log.Println("CALLBACK_SECRETS is not set, provider callbacks will be rejected")
 	}
+
+	messageRouter := api.NewAPI(db, redisClient, messageService, callbackAuth)
+	messageRouter.RegisterRoutes(router)
+
+	server := &http.Server{Addr: ":8080", Handler: router}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Println("Server failed:", err)
+			stop()
+		}
+	}()
 	fmt.Println("Server is running on port 8080")
+
+	<-ctx.Done()
+	log.Println("Shutting down.")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Failed to shut down server:", err)
+	}
+
+	// let the run put the messages it didn't send back to pending
+	messageService.Wait()
+	if resigned != nil {
+		<-resigned
+	}
 }